@@ -11,10 +11,21 @@ func init() {
 	cmdQuery := &cobra.Command{
 		Use:   "balances",
 		Short: "Check account balance on all shards",
-		Long:  `Query for the latest account balance given a Harmony Address`,
-		Args:  cobra.ExactArgs(1),
+		Long: `
+Query for the latest account balance given a Harmony Address, given several
+addresses the balances are keyed by address
+`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			r, err := sharding.CheckAllShards(node, args[0], noPrettyOutput)
+			ctx, cancel := commandContext()
+			defer cancel()
+			var r string
+			var err error
+			if len(args) == 1 {
+				r, err = sharding.CheckAllShardsUsing(ctx, dialNode, node, args[0], noPrettyOutput)
+			} else {
+				r, err = sharding.CheckAllShardsForAddressesUsing(ctx, dialNode, node, args, noPrettyOutput)
+			}
 			if err != nil {
 				return err
 			}
@@ -104,6 +104,8 @@ High level information about transaction, like blockNumber, blockHash
 	cmdDelegation.AddCommand(delegationSubCmds[:]...)
 
 	cmdBlockchain.AddCommand(subCommands[:]...)
+	cmdBlockchain.AddCommand(subscribeSubCmd())
+	cmdBlockchain.AddCommand(logsSubCmd())
 
 	RootCmd.AddCommand(cmdBlockchain)
 }
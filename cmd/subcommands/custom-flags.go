@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/harmony-one/go-sdk/pkg/address"
 	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
 	"github.com/harmony-one/go-sdk/pkg/validation"
 	"github.com/pkg/errors"
 )
@@ -53,3 +59,50 @@ func (chainIDWrapper *chainIDWrapper) Set(s string) error {
 func (chainIDWrapper chainIDWrapper) Type() string {
 	return "string"
 }
+
+// rateLimitsFlag takes [<host>=]<calls per second>[/<burst>], repeated for several
+// hosts, a limit without a host applies to every other endpoint
+type rateLimitsFlag struct {
+	limits *rpc.RateLimits
+	given  []string
+}
+
+func (rateLimitsFlag rateLimitsFlag) String() string {
+	return strings.Join(rateLimitsFlag.given, ",")
+}
+
+func (rateLimitsFlag *rateLimitsFlag) Set(s string) error {
+	host, value := "", s
+	if i := strings.Index(s, "="); i >= 0 {
+		host, value = strings.TrimSpace(s[:i]), s[i+1:]
+	}
+	limit := rpc.RateLimit{}
+	burst := ""
+	if i := strings.Index(value, "/"); i >= 0 {
+		value, burst = value[:i], value[i+1:]
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate < 0 {
+		return fmt.Errorf("invalid rate limit %s, expected [<host>=]<calls per second>[/<burst>]", s)
+	}
+	limit.PerSecond, limit.Burst = rate, int(math.Ceil(rate))
+	if burst != "" {
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || limit.Burst < 1 {
+			return fmt.Errorf("invalid burst in rate limit %s", s)
+		}
+	}
+	if host == "" {
+		rateLimitsFlag.limits.Default = limit
+	} else {
+		if rateLimitsFlag.limits.PerHost == nil {
+			rateLimitsFlag.limits.PerHost = make(map[string]rpc.RateLimit)
+		}
+		rateLimitsFlag.limits.PerHost[host] = limit
+	}
+	rateLimitsFlag.given = append(rateLimitsFlag.given, s)
+	return nil
+}
+
+func (rateLimitsFlag rateLimitsFlag) Type() string {
+	return "stringSlice"
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/spf13/cobra"
+)
+
+const logPollInterval = 2 * time.Second
+
+var (
+	logFromBlock string
+	logToBlock   string
+	followLogs   bool
+)
+
+// blockFlag accepts a decimal or 0x block number or one of latest, earliest and pending
+func blockFlag(value string) (string, error) {
+	switch value {
+	case "", "latest", "earliest", "pending":
+		return value, nil
+	}
+	if strings.HasPrefix(value, "0x") {
+		if _, err := hexutil.DecodeUint64(value); err != nil {
+			return "", fmt.Errorf("invalid block %s", value)
+		}
+		return value, nil
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid block %s", value)
+	}
+	return hexutil.EncodeUint64(number), nil
+}
+
+func logsSubCmd() *cobra.Command {
+	cmdLogs := &cobra.Command{
+		Use:   "logs",
+		Short: "Look up the logs emitted by contracts, optionally following new ones",
+		Args:  cobra.NoArgs,
+		Long: `
+Print the logs matching --address and --topics between --from-block and --to-block,
+both default to the latest block. With --follow, the logs since --from-block are
+printed as JSON lines followed by new ones as they land, until interrupted
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := blockFlag(logFromBlock)
+			if err != nil {
+				return err
+			}
+			to, err := blockFlag(logToBlock)
+			if err != nil {
+				return err
+			}
+			if followLogs && to != "" {
+				return errors.New("--to-block cannot be combined with --follow")
+			}
+			q := rpc.FilterQuery{
+				Address:   logAddresses,
+				Topics:    topicsQuery(logTopics),
+				FromBlock: from,
+				ToBlock:   to,
+			}
+			// Filters live on the node that created them, round robin would lose them
+			roundRobin = false
+			messenger := handlerForNodes(rpc.SplitNodes(node))
+			if closer, ok := messenger.(io.Closer); ok {
+				defer closer.Close()
+			}
+			manager := rpc.NewFilterManager(messenger)
+			ctx, cancel := commandContext()
+			defer cancel()
+			if !followLogs {
+				logs, err := manager.GetLogs(ctx, q)
+				if err != nil {
+					return err
+				}
+				fmt.Println(common.ToJSONUnsafe(logs, !noPrettyOutput))
+				return nil
+			}
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			defer signal.Stop(interrupt)
+			go func() {
+				select {
+				case <-interrupt:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			return manager.FollowLogs(ctx, q, logPollInterval, func(log rpc.Log) {
+				fmt.Println(common.ToJSONUnsafe(log, false))
+			})
+		},
+	}
+	cmdLogs.Flags().StringSliceVar(&logAddresses, "address", []string{}, "only logs emitted by these contracts")
+	cmdLogs.Flags().StringSliceVar(&logTopics, "topics",
+		[]string{}, "topics by position, use | between alternatives and leave empty to match any",
+	)
+	cmdLogs.Flags().StringVar(&logFromBlock, "from-block", "", "first block to look at, a number, latest or earliest")
+	cmdLogs.Flags().StringVar(&logToBlock, "to-block", "", "last block to look at, a number, latest or earliest")
+	cmdLogs.Flags().BoolVar(&followLogs, "follow", false, "keep printing new logs as JSON lines until interrupted")
+	return cmdLogs
+}
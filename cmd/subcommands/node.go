@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
+	"github.com/spf13/cobra"
+)
+
+var (
+	maxBlocksBehind uint64
+	maxHeaderAge    time.Duration
+)
+
+// nodeInfo is the metadata of a node along with the protocol version it speaks
+type nodeInfo struct {
+	*rpc.NodeMetadata
+	ProtocolVersion uint64 `json:"protocol-version"`
+}
+
+func init() {
+	cmdNode := &cobra.Command{
+		Use:   "node",
+		Short: "Inspect the node the CLI talks to",
+		Long: `
+Look up what the --node is, the network it belongs to and how it is doing
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmdNode.AddCommand(&cobra.Command{
+		Use:   "info",
+		Short: "Version, network, chain-id, shard and role of the node",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext()
+			defer cancel()
+			messenger := handlerForNodes(rpc.SplitNodes(node))
+			if closer, ok := messenger.(io.Closer); ok {
+				defer closer.Close()
+			}
+			client := rpc.NewClient(messenger)
+			metadata, err := client.GetNodeMetadata(ctx)
+			if err != nil {
+				return err
+			}
+			version, err := client.ProtocolVersion(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Println(common.ToJSONUnsafe(nodeInfo{metadata, version}, !noPrettyOutput))
+			return nil
+		},
+	})
+
+	cmdHealth := &cobra.Command{
+		Use:   "health",
+		Short: "Height, peers, sync state and header age of every shard, fails if one lags",
+		Args:  cobra.NoArgs,
+		Long: `
+Ask the endpoint of every shard of the network --node belongs to how it is doing.
+Exits non-zero when a shard cannot be reached, is more than --max-blocks-behind
+blocks behind while syncing or its latest header is older than --max-header-age,
+so it can serve as a monitoring probe
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := commandContext()
+			defer cancel()
+			report, err := sharding.HealthUsing(ctx, dialNode, node)
+			if err != nil {
+				return err
+			}
+			now, lagging := time.Now(), 0
+			table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(table, "SHARD\tENDPOINT\tHEIGHT\tPEERS\tSYNCING\tBEHIND\tHEADER AGE\tSTATUS")
+			for _, shard := range report {
+				status := "ok"
+				age := shard.HeaderAge(now)
+				switch {
+				case shard.Error != "":
+					status = shard.Error
+				case shard.BlocksBehind > maxBlocksBehind:
+					status = fmt.Sprintf("more than %d blocks behind", maxBlocksBehind)
+				case maxHeaderAge > 0 && age > maxHeaderAge:
+					status = fmt.Sprintf("no block for more than %s", maxHeaderAge)
+				}
+				if status != "ok" {
+					lagging++
+				}
+				ageText := "unknown"
+				if !shard.HeaderTime.IsZero() {
+					ageText = age.Truncate(time.Second).String()
+				}
+				fmt.Fprintf(table, "%d\t%s\t%d\t%d\t%t\t%d\t%s\t%s\n",
+					shard.ShardID, shard.Endpoint, shard.Height, shard.Peers,
+					shard.Syncing, shard.BlocksBehind, ageText, status,
+				)
+			}
+			table.Flush()
+			if lagging > 0 {
+				return fmt.Errorf("%d of %d shards unhealthy", lagging, len(report))
+			}
+			return nil
+		},
+	}
+	cmdHealth.Flags().Uint64Var(&maxBlocksBehind, "max-blocks-behind", 10, "fail when a syncing shard is more than this many blocks behind")
+	cmdHealth.Flags().DurationVar(&maxHeaderAge, "max-header-age", time.Minute, "fail when the latest block of a shard is older, 0 disables the check")
+	cmdNode.AddCommand(cmdHealth)
+
+	RootCmd.AddCommand(cmdNode)
+}
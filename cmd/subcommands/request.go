@@ -1 +1,68 @@
 package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	allowUnknownMethod bool
+)
+
+// paramsFromArgs decodes each argument as JSON, anything that is not valid JSON
+// is taken as a plain string so hashes and addresses need no quoting
+func paramsFromArgs(args []string) []interface{} {
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(arg)))
+		decoder.UseNumber()
+		var param interface{}
+		if err := decoder.Decode(&param); err != nil || decoder.More() {
+			param = arg
+		}
+		params[i] = param
+	}
+	return params
+}
+
+// allowMethod registers method when it is unknown, taking any arity parameters,
+// so the messenger sends it
+func allowMethod(method string, arity int) {
+	if _, known := rpc.LookupMethod(method); known {
+		return
+	}
+	params := make([]rpc.ParamKind, arity)
+	for i := range params {
+		params[i] = rpc.ParamAny
+	}
+	rpc.RegisterMethod(method, rpc.MethodSpec{Params: params})
+}
+
+func init() {
+	cmdRPC := &cobra.Command{
+		Use:   "rpc <method> [params-json...]",
+		Short: "Call any RPC method of the node",
+		Args:  cobra.MinimumNArgs(1),
+		Long: `
+Call an RPC method by name, each further argument is one parameter given as JSON,
+arguments that are not valid JSON are sent as strings. Methods and parameters are
+checked against the known methods, use --allow-unknown to send anything
+`,
+		Example: `hmy rpc hmy_getBalance one1... latest
+hmy rpc hmy_getBlockByNumber 0x10 true`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			method, params := args[0], paramsFromArgs(args[1:])
+			if allowUnknownMethod {
+				allowMethod(method, len(params))
+			} else if err := rpc.ValidateCall(method, params); err != nil {
+				return err
+			}
+			return printReply(method, params)
+		},
+	}
+	cmdRPC.Flags().BoolVar(&allowUnknownMethod, "allow-unknown", false, "skip checking the method and its parameters")
+	RootCmd.AddCommand(cmdRPC)
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"time"
 
 	"github.com/harmony-one/go-sdk/pkg/common"
 	"github.com/harmony-one/go-sdk/pkg/rpc"
@@ -20,11 +23,25 @@ var (
 	noPrettyOutput  bool
 	node            string
 	keyStoreDir     string
+	timeout         time.Duration
+	roundRobin      bool
+	diskCache       bool
+	rateLimits      = &rpc.RateLimits{}
 	request         = func(method string, params []interface{}) error {
 		if !noLatest {
 			params = append(params, "latest")
 		}
-		success, failure := rpc.Request(method, node, params)
+		return printReply(method, params)
+	}
+	// printReply sends params to the node exactly as given and prints what comes back
+	printReply = func(method string, params []interface{}) error {
+		ctx, cancel := commandContext()
+		defer cancel()
+		messenger := cachedHandler(node, handlerForNodes(rpc.SplitNodes(node)))
+		if closer, ok := messenger.(io.Closer); ok {
+			defer closer.Close()
+		}
+		success, failure := rpc.SendRPCContext(ctx, messenger, method, params)
 		if failure != nil {
 			return failure
 		}
@@ -59,9 +76,18 @@ CLI interface to the Harmony blockchain
 func init() {
 	vS := "dump out debug information, same as env var HMY_ALL_DEBUG=true"
 	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, vS)
-	RootCmd.PersistentFlags().StringVarP(&node, "node", "n", defaultNodeAddr, "<host>")
+	RootCmd.PersistentFlags().StringVarP(&node, "node", "n", defaultNodeAddr, "<host>, use ws:// or wss:// for a WebSocket connection, comma separate several for failover")
 	RootCmd.PersistentFlags().BoolVar(&noLatest, "no-latest", false, "Do not add 'latest' to RPC params")
 	RootCmd.PersistentFlags().BoolVar(&noPrettyOutput, "no-pretty", false, "Disable pretty print JSON outputs")
+	RootCmd.PersistentFlags().BoolVar(&roundRobin, "round-robin", false, "Spread reads over all the nodes given to --node")
+	RootCmd.PersistentFlags().BoolVar(&diskCache, "disk-cache", false, "Keep blocks, receipts and the sharding structure on disk between runs")
+	RootCmd.PersistentFlags().Var(&rateLimitsFlag{limits: rateLimits}, "rate-limit",
+		"Calls per second allowed to each endpoint, as [<host>=]<rate>[/<burst>], repeat for other hosts",
+	)
+	RootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, fmt.Sprintf(
+		"Give up on the node after this long, e.g. 30s, 0 waits forever over HTTP and %s per call over WebSocket",
+		rpc.DefaultWSCallTimeout,
+	))
 	RootCmd.AddCommand(&cobra.Command{
 		Use:   "cookbook",
 		Short: "Example usages of the most important, frequently used commands",
@@ -82,6 +108,14 @@ func init() {
 	})
 }
 
+// commandContext bounds the RPC calls of a command by --timeout
+func commandContext() (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
 // Execute kicks off the hmy CLI
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
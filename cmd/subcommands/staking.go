@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/harmony-one/go-sdk/pkg/common"
 	"github.com/harmony-one/go-sdk/pkg/ledger"
 	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
 	"github.com/harmony-one/go-sdk/pkg/store"
 
 	"math/big"
@@ -47,12 +49,12 @@ var (
 	commisionMaxChangeRateStr string
 	slotKeyToRemove           string
 	slotKeyToAdd              string
-	minSelfDelegation         float64
-	maxTotalDelegation        float64
+	minSelfDelegation         common.Amount
+	maxTotalDelegation        common.Amount
 	stakingBlsPubKeys         []string
 	delegatorAddress          oneAddress
 	validatorAddress          oneAddress
-	stakingAmount             float64
+	stakingAmount             common.Amount
 )
 
 var (
@@ -69,20 +71,27 @@ var (
 	errInvalidDescFieldDetails         = errors.New("exceeds maximum length of 280 characters for description field details")
 )
 
-func getNextNonce(addr oneAddress, messenger rpc.T) uint64 {
-	transactionCountRPCReply, err :=
-		messenger.SendRPC(rpc.Method.GetTransactionCount, []interface{}{address.Parse(addr.String()), "latest"})
+// getNextNonce reserves the next nonce of addr on the beacon shard, handleStakingTransaction reports what became of it
+func getNextNonce(ctx context.Context, addr oneAddress, messenger rpc.T) (uint64, error) {
+	return nonces.Next(ctx, messenger, addr.String(), sharding.BeaconShard)
+}
 
+// createStakingTransaction builds the staking transaction of signer with its next nonce
+func createStakingTransaction(
+	ctx context.Context, messenger rpc.T, signer oneAddress, f staking.StakeMsgFulfiller,
+) (*staking.StakingTransaction, error) {
+	nonce, err := getNextNonce(ctx, signer, messenger)
 	if err != nil {
-		return 0
+		return nil, err
 	}
-
-	transactionCount, _ := transactionCountRPCReply["result"].(string)
-	nonce, _ := big.NewInt(0).SetString(transactionCount[2:], 16)
-	return nonce.Uint64()
+	stakingTx, err := newStakingTransaction(nonce, f)
+	if err != nil {
+		nonces.Release(signer.String(), sharding.BeaconShard, nonce)
+	}
+	return stakingTx, err
 }
 
-func createStakingTransaction(nonce uint64, f staking.StakeMsgFulfiller) (*staking.StakingTransaction, error) {
+func newStakingTransaction(nonce uint64, f staking.StakeMsgFulfiller) (*staking.StakingTransaction, error) {
 	gasPrice := big.NewInt(gasPrice)
 	gasPrice = gasPrice.Mul(gasPrice, big.NewInt(denominations.Nano))
 
@@ -102,14 +111,20 @@ func createStakingTransaction(nonce uint64, f staking.StakeMsgFulfiller) (*staki
 }
 
 func handleStakingTransaction(
-	stakingTx *staking.StakingTransaction, networkHandler *rpc.HTTPMessenger, signerAddress oneAddress,
-) error {
+	ctx context.Context, stakingTx *staking.StakingTransaction, networkHandler rpc.T, signerAddress oneAddress,
+) (err error) {
 	var ks *keystore.KeyStore
 	var acct *accounts.Account
 	var signed *staking.StakingTransaction
-	var err error
 
-	from := signerAddress.String()
+	from, sent := signerAddress.String(), false
+	defer func() {
+		if !sent {
+			nonces.Release(from, sharding.BeaconShard, stakingTx.Nonce())
+			return
+		}
+		nonces.Done(from, sharding.BeaconShard, stakingTx.Nonce(), err)
+	}()
 
 	if useLedgerWallet {
 		var signerAddr string
@@ -139,7 +154,10 @@ func handleStakingTransaction(
 	}
 
 	hexSignature := hexutil.Encode(enc)
-	reply, err := networkHandler.SendRPC(rpc.Method.SendRawStakingTransaction, []interface{}{hexSignature})
+	sent = true
+	reply, err := rpc.SendRPCContext(
+		ctx, networkHandler, rpc.Method.SendRawStakingTransaction, []interface{}{hexSignature},
+	)
 	if err != nil {
 		return err
 	}
@@ -223,7 +241,14 @@ func stakingSubCommands() []*cobra.Command {
 Create a new validator"
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			networkHandler, err := handlerForShard(0, node)
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(sharding.BeaconShard)
 			if err != nil {
 				return err
 			}
@@ -254,14 +279,11 @@ Create a new validator"
 				blsPubKeys[i].FromLibBLSPublicKey(blsPubKey)
 			}
 
-			amountBigInt := big.NewInt(int64(stakingAmount * denominations.Nano))
-			amt := amountBigInt.Mul(amountBigInt, big.NewInt(denominations.Nano))
+			amt := stakingAmount.Atto()
 
-			minSelfDelegationBigInt := big.NewInt(int64(minSelfDelegation * denominations.Nano))
-			minSelfDel := minSelfDelegationBigInt.Mul(minSelfDelegationBigInt, big.NewInt(denominations.Nano))
+			minSelfDel := minSelfDelegation.Atto()
 
-			maxTotalDelegationBigInt := big.NewInt(int64(maxTotalDelegation * denominations.Nano))
-			maxTotalDel := maxTotalDelegationBigInt.Mul(maxTotalDelegationBigInt, big.NewInt(denominations.Nano))
+			maxTotalDel := maxTotalDelegation.Atto()
 
 			err = delegationAmountSanityCheck(minSelfDel, maxTotalDel, amt)
 			if err != nil {
@@ -299,12 +321,12 @@ Create a new validator"
 				}
 			}
 
-			stakingTx, err := createStakingTransaction(getNextNonce(validatorAddress, networkHandler), delegateStakePayloadMaker)
+			stakingTx, err := createStakingTransaction(ctx, networkHandler, validatorAddress, delegateStakePayloadMaker)
 			if err != nil {
 				return err
 			}
 
-			err = handleStakingTransaction(stakingTx, networkHandler, validatorAddress)
+			err = handleStakingTransaction(ctx, stakingTx, networkHandler, validatorAddress)
 			if err != nil {
 				return err
 			}
@@ -320,11 +342,11 @@ Create a new validator"
 	subCmdNewValidator.Flags().StringVar(&commisionRateStr, "rate", "", "commission rate")
 	subCmdNewValidator.Flags().StringVar(&commisionMaxRateStr, "max-rate", "", "commision max rate")
 	subCmdNewValidator.Flags().StringVar(&commisionMaxChangeRateStr, "max-change-rate", "", "commission max change amount")
-	subCmdNewValidator.Flags().Float64Var(&minSelfDelegation, "min-self-delegation", 0.0, "minimal self delegation")
-	subCmdNewValidator.Flags().Float64Var(&maxTotalDelegation, "max-total-delegation", 0.0, "maximal total delegation")
+	subCmdNewValidator.Flags().Var(&minSelfDelegation, "min-self-delegation", "minimal self delegation in ONE")
+	subCmdNewValidator.Flags().Var(&maxTotalDelegation, "max-total-delegation", "maximal total delegation in ONE")
 	subCmdNewValidator.Flags().Var(&validatorAddress, "validator-addr", "validator's staking address")
 	subCmdNewValidator.Flags().StringSliceVar(&stakingBlsPubKeys, "bls-pubkeys", []string{}, "validator's list of public BLS key addresses")
-	subCmdNewValidator.Flags().Float64Var(&stakingAmount, "amount", 0.0, "staking amount")
+	subCmdNewValidator.Flags().Var(&stakingAmount, "amount", "staking amount in ONE")
 	subCmdNewValidator.Flags().Int64Var(&gasPrice, "gas-price", 1, "gas price to pay")
 	subCmdNewValidator.Flags().Var(&chainName, "chain-id", "what chain ID to target")
 	subCmdNewValidator.Flags().StringVar(&unlockP,
@@ -344,7 +366,14 @@ Create a new validator"
 Edit an existing validator"
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			networkHandler, err := handlerForShard(0, node)
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(sharding.BeaconShard)
 			if err != nil {
 				return err
 			}
@@ -372,11 +401,9 @@ Edit an existing validator"
 			shardPubKeyAdd := shard.BlsPublicKey{}
 			shardPubKeyAdd.FromLibBLSPublicKey(blsPubKeyAdd)
 
-			minSelfDelegationBigInt := big.NewInt(int64(minSelfDelegation * denominations.Nano))
-			minSelfDel := minSelfDelegationBigInt.Mul(minSelfDelegationBigInt, big.NewInt(denominations.Nano))
+			minSelfDel := minSelfDelegation.Atto()
 
-			maxTotalDelegationBigInt := big.NewInt(int64(maxTotalDelegation * denominations.Nano))
-			maxTotalDel := maxTotalDelegationBigInt.Mul(maxTotalDelegationBigInt, big.NewInt(denominations.Nano))
+			maxTotalDel := maxTotalDelegation.Atto()
 
 			err = delegationAmountSanityCheck(minSelfDel, maxTotalDel, nil)
 			if err != nil {
@@ -407,12 +434,12 @@ Edit an existing validator"
 
 			}
 
-			stakingTx, err := createStakingTransaction(getNextNonce(validatorAddress, networkHandler), delegateStakePayloadMaker)
+			stakingTx, err := createStakingTransaction(ctx, networkHandler, validatorAddress, delegateStakePayloadMaker)
 			if err != nil {
 				return err
 			}
 
-			err = handleStakingTransaction(stakingTx, networkHandler, validatorAddress)
+			err = handleStakingTransaction(ctx, stakingTx, networkHandler, validatorAddress)
 			if err != nil {
 				return err
 			}
@@ -426,8 +453,8 @@ Edit an existing validator"
 	subCmdEditValidator.Flags().StringVar(&validatorSecurityContact, "security-contact", "", "validator's security contact")
 	subCmdEditValidator.Flags().StringVar(&validatorDetails, "details", "", "validator's details")
 	subCmdEditValidator.Flags().StringVar(&commisionRateStr, "rate", "", "commission rate")
-	subCmdEditValidator.Flags().Float64Var(&minSelfDelegation, "min-self-delegation", 0.0, "minimal self delegation")
-	subCmdEditValidator.Flags().Float64Var(&maxTotalDelegation, "max-total-delegation", 0.0, "maximal total delegation")
+	subCmdEditValidator.Flags().Var(&minSelfDelegation, "min-self-delegation", "minimal self delegation in ONE")
+	subCmdEditValidator.Flags().Var(&maxTotalDelegation, "max-total-delegation", "maximal total delegation in ONE")
 	subCmdEditValidator.Flags().Var(&validatorAddress, "validator-addr", "validator's staking address")
 	subCmdEditValidator.Flags().StringVar(&slotKeyToAdd, "add-bls-key", "", "add BLS pubkey to slot")
 	subCmdEditValidator.Flags().StringVar(&slotKeyToRemove, "remove-bls-key", "", "remove BLS pubkey from slot")
@@ -451,14 +478,20 @@ Edit an existing validator"
 Delegating to a validator
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			networkHandler, err := handlerForShard(0, node)
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(sharding.BeaconShard)
 			if err != nil {
 				return err
 			}
 
 			delegateStakePayloadMaker := func() (staking.Directive, interface{}) {
-				amountBigInt := big.NewInt(int64(stakingAmount * denominations.Nano))
-				amt := amountBigInt.Mul(amountBigInt, big.NewInt(denominations.Nano))
+				amt := stakingAmount.Atto()
 
 				return staking.DirectiveDelegate, staking.Delegate{
 					address.Parse(delegatorAddress.String()),
@@ -467,12 +500,12 @@ Delegating to a validator
 				}
 			}
 
-			stakingTx, err := createStakingTransaction(getNextNonce(delegatorAddress, networkHandler), delegateStakePayloadMaker)
+			stakingTx, err := createStakingTransaction(ctx, networkHandler, delegatorAddress, delegateStakePayloadMaker)
 			if err != nil {
 				return err
 			}
 
-			err = handleStakingTransaction(stakingTx, networkHandler, delegatorAddress)
+			err = handleStakingTransaction(ctx, stakingTx, networkHandler, delegatorAddress)
 			if err != nil {
 				return err
 			}
@@ -482,7 +515,7 @@ Delegating to a validator
 
 	subCmdDelegate.Flags().Var(&delegatorAddress, "delegator-addr", "delegator's address")
 	subCmdDelegate.Flags().Var(&validatorAddress, "validator-addr", "validator's address")
-	subCmdDelegate.Flags().Float64Var(&stakingAmount, "amount", 0.0, "staking amount")
+	subCmdDelegate.Flags().Var(&stakingAmount, "amount", "staking amount in ONE")
 	subCmdDelegate.Flags().Int64Var(&gasPrice, "gas-price", 1, "gas price to pay")
 	subCmdDelegate.Flags().Var(&chainName, "chain-id", "what chain ID to target")
 	subCmdDelegate.Flags().StringVar(&unlockP,
@@ -501,14 +534,20 @@ Delegating to a validator
  Removing delegation responsibility
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			networkHandler, err := handlerForShard(0, node)
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(sharding.BeaconShard)
 			if err != nil {
 				return err
 			}
 
 			delegateStakePayloadMaker := func() (staking.Directive, interface{}) {
-				amountBigInt := big.NewInt(int64(stakingAmount * denominations.Nano))
-				amt := amountBigInt.Mul(amountBigInt, big.NewInt(denominations.Nano))
+				amt := stakingAmount.Atto()
 
 				return staking.DirectiveUndelegate, staking.Undelegate{
 					address.Parse(delegatorAddress.String()),
@@ -517,12 +556,12 @@ Delegating to a validator
 				}
 			}
 
-			stakingTx, err := createStakingTransaction(getNextNonce(delegatorAddress, networkHandler), delegateStakePayloadMaker)
+			stakingTx, err := createStakingTransaction(ctx, networkHandler, delegatorAddress, delegateStakePayloadMaker)
 			if err != nil {
 				return err
 			}
 
-			err = handleStakingTransaction(stakingTx, networkHandler, delegatorAddress)
+			err = handleStakingTransaction(ctx, stakingTx, networkHandler, delegatorAddress)
 			if err != nil {
 				return err
 			}
@@ -532,7 +571,7 @@ Delegating to a validator
 
 	subCmdUnDelegate.Flags().Var(&delegatorAddress, "delegator-addr", "delegator's address")
 	subCmdUnDelegate.Flags().Var(&validatorAddress, "validator-addr", "source validator's address")
-	subCmdUnDelegate.Flags().Float64Var(&stakingAmount, "amount", 0.0, "staking amount")
+	subCmdUnDelegate.Flags().Var(&stakingAmount, "amount", "staking amount in ONE")
 	subCmdUnDelegate.Flags().Int64Var(&gasPrice, "gas-price", 1, "gas price to pay")
 	subCmdUnDelegate.Flags().Var(&chainName, "chain-id", "what chain ID to target")
 	subCmdUnDelegate.Flags().StringVar(&unlockP,
@@ -551,7 +590,14 @@ Delegating to a validator
 Collect token rewards
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			networkHandler, err := handlerForShard(0, node)
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(sharding.BeaconShard)
 			if err != nil {
 				return err
 			}
@@ -562,12 +608,12 @@ Collect token rewards
 				}
 			}
 
-			stakingTx, err := createStakingTransaction(getNextNonce(delegatorAddress, networkHandler), delegateStakePayloadMaker)
+			stakingTx, err := createStakingTransaction(ctx, networkHandler, delegatorAddress, delegateStakePayloadMaker)
 			if err != nil {
 				return err
 			}
 
-			err = handleStakingTransaction(stakingTx, networkHandler, delegatorAddress)
+			err = handleStakingTransaction(ctx, stakingTx, networkHandler, delegatorAddress)
 			if err != nil {
 				return err
 			}
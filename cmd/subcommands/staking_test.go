@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/harmony/accounts/keystore"
+	"github.com/harmony-one/harmony/common/denominations"
+	hmybls "github.com/harmony-one/harmony/crypto/bls"
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/store"
+)
+
+// localAccount imports a fresh key into a keystore under a temporary home, the
+// returned function restores the home
+func localAccount(t *testing.T) (string, func()) {
+	home, err := ioutil.TempDir("", "hmy-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+	restore := func() {
+		os.Setenv("HOME", previous)
+		homedir.DisableCache = false
+		homedir.Reset()
+		os.RemoveAll(home)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		restore()
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStore(
+		filepath.Join(store.DefaultLocation(), "staker"), keystore.LightScryptN, keystore.LightScryptP,
+	)
+	account, err := ks.ImportECDSA(key, common.DefaultPassphrase)
+	if err != nil {
+		restore()
+		t.Fatal(err)
+	}
+	return address.ToBech32(account.Address), restore
+}
+
+// hmy runs the CLI against node
+func hmy(node string, args ...string) error {
+	RootCmd.SetArgs(append(args, "--node", node, "--chain-id", "testnet"))
+	return RootCmd.Execute()
+}
+
+func TestStakingCommandsAgainstMockNode(t *testing.T) {
+	validator, restore := localAccount(t)
+	defer restore()
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	one := big.NewInt(denominations.One)
+	node.SetBalance(validator, new(big.Int).Mul(big.NewInt(100), one))
+	blsKey := hmybls.RandPrivateKey().GetPublicKey().SerializeToHexStr()
+
+	if err := hmy(node.URL, "staking", "create-validator", "--validator-addr", validator,
+		"--name", "mock", "--identity", "mock", "--website", "mock.one", "--security-contact", "mock",
+		"--details", "mock", "--rate", "0.1", "--max-rate", "0.9", "--max-change-rate", "0.05",
+		"--min-self-delegation", "10", "--max-total-delegation", "50", "--bls-pubkeys", blsKey,
+		"--amount", "10",
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := hmy(node.URL, "staking", "delegate",
+		"--delegator-addr", validator, "--validator-addr", validator, "--amount", "5",
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := hmy(node.URL, "staking", "undelegate",
+		"--delegator-addr", validator, "--validator-addr", validator, "--amount", "3",
+	); err != nil {
+		t.Fatal(err)
+	}
+	// The node insists on the next nonce, a reused one fails the transfer
+	receiver := address.ToBech32(address.Parse("0x00000000000000000000000000000000000000b2"))
+	if err := hmy(node.URL, "transfer", "--from", validator, "--to", receiver,
+		"--amount", "1", "--from-shard", "0", "--to-shard", "0",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if nonce := node.Nonce(validator); nonce != 4 {
+		t.Errorf("expected 4 transactions from the validator, the node counts %d", nonce)
+	}
+	created, ok := node.Validator(validator)
+	if !ok {
+		t.Fatal("validator was not created")
+	}
+	if created.Name != "mock" || len(created.BLSPublicKeys) != 1 || len(created.Delegations) != 1 {
+		t.Fatalf("unexpected validator %+v", created)
+	}
+	delegation := created.Delegations[0]
+	if expected := new(big.Int).Mul(big.NewInt(12), one); delegation.Amount.Cmp(expected) != 0 {
+		t.Errorf("expected a self delegation of %s, got %s", expected, delegation.Amount)
+	}
+	if len(delegation.Undelegations) != 1 || delegation.Undelegations[0].Amount.Cmp(new(big.Int).Mul(big.NewInt(3), one)) != 0 {
+		t.Errorf("unexpected undelegations %+v", delegation.Undelegations)
+	}
+	if balance := node.Balance(receiver); balance.Cmp(one) != 0 {
+		t.Errorf("receiver got %s", balance)
+	}
+}
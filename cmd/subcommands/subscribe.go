@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logAddresses []string
+	logTopics    []string
+)
+
+// topicsQuery turns "a|b,,c" style flag values into per position topic alternatives
+func topicsQuery(topics []string) [][]string {
+	query := make([][]string, len(topics))
+	for i, position := range topics {
+		if position == "" {
+			continue
+		}
+		query[i] = strings.Split(position, "|")
+	}
+	return query
+}
+
+// forward relays every value received off channel, of any element type, onto
+// one untyped feed that is closed along with channel
+func forward(channel interface{}) <-chan interface{} {
+	feed := make(chan interface{})
+	go func() {
+		defer close(feed)
+		from := reflect.ValueOf(channel)
+		for v, ok := from.Recv(); ok; v, ok = from.Recv() {
+			feed <- v.Interface()
+		}
+	}()
+	return feed
+}
+
+// streamJSONLines prints every value off feed as one JSON document per line
+// until the feed ends, the subscription fails or the user interrupts
+func streamJSONLines(feed <-chan interface{}, sub *rpc.Subscription) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	for {
+		select {
+		case v, ok := <-feed:
+			if !ok {
+				return <-sub.Err()
+			}
+			fmt.Println(common.ToJSONUnsafe(v, false))
+		case err := <-sub.Err():
+			return err
+		case <-interrupt:
+			return sub.Unsubscribe()
+		}
+	}
+}
+
+func subscribeSubCmd() *cobra.Command {
+	cmdSubscribe := &cobra.Command{
+		Use:       "subscribe <new-heads|logs|pending-transactions>",
+		Short:     "Stream notifications from a WebSocket node as JSON lines",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"new-heads", "logs", "pending-transactions"},
+		Long: `
+Subscribe to new block headers, filtered logs or pending transaction hashes,
+requires a ws:// or wss:// --node
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// A subscription lives on one connection, only the first node is used
+			nodes := rpc.SplitNodes(node)
+			if len(nodes) == 0 || !rpc.IsWebSocket(nodes[0]) {
+				return errors.New("subscriptions need a WebSocket node, use a ws:// or wss:// --node")
+			}
+			messenger := rpc.NewWSHandler(nodes[0])
+			defer messenger.Close()
+			var notifications interface{}
+			var sub *rpc.Subscription
+			var err error
+			switch args[0] {
+			case "new-heads":
+				notifications, sub, err = messenger.SubscribeNewHeads()
+			case "logs":
+				notifications, sub, err = messenger.SubscribeLogs(rpc.FilterQuery{
+					Address: logAddresses,
+					Topics:  topicsQuery(logTopics),
+				})
+			case "pending-transactions":
+				notifications, sub, err = messenger.SubscribePendingTransactions()
+			default:
+				return fmt.Errorf("unknown subscription %s", args[0])
+			}
+			if err != nil {
+				return err
+			}
+			return streamJSONLines(forward(notifications), sub)
+		},
+	}
+	cmdSubscribe.Flags().StringSliceVar(&logAddresses, "address", []string{}, "only logs emitted by these contracts")
+	cmdSubscribe.Flags().StringSliceVar(&logTopics, "topics",
+		[]string{}, "topics by position, use | between alternatives and leave empty to match any",
+	)
+	return cmdSubscribe
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestForwardRelaysUntilTheChannelCloses(t *testing.T) {
+	logs := make(chan *rpc.Log, 2)
+	logs <- &rpc.Log{Data: "0x01"}
+	logs <- &rpc.Log{Data: "0x02"}
+	close(logs)
+	got := []string{}
+	for v := range forward((<-chan *rpc.Log)(logs)) {
+		got = append(got, v.(*rpc.Log).Data)
+	}
+	if len(got) != 2 || got[0] != "0x01" || got[1] != "0x02" {
+		t.Errorf("forwarded %v", got)
+	}
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/harmony-one/go-sdk/pkg/address"
 	"github.com/harmony-one/go-sdk/pkg/common"
@@ -11,6 +16,7 @@ import (
 	"github.com/harmony-one/go-sdk/pkg/transaction"
 	"github.com/harmony-one/go-sdk/pkg/validation"
 	"github.com/harmony-one/harmony/accounts"
+	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
 )
@@ -18,7 +24,7 @@ import (
 var (
 	fromAddress oneAddress
 	toAddress   oneAddress
-	amount      float64
+	amount      common.Amount
 	fromShardID uint32
 	toShardID   uint32
 	confirmWait uint32
@@ -26,23 +32,109 @@ var (
 	dryRun      bool
 	unlockP     string
 	gasPrice    int64
+	// transferGasPrice is kept apart from the gasPrice of staking, 0 lets the node suggest one
+	transferGasPrice int64
+	gasLimit         uint64
+	gasMargin        uint64
+	inputData        string
+	inputDataFile    string
+	// nonces counts the transactions of this process still pending on top of what the node reports
+	nonces = transaction.NewNonceManager()
 )
 
-func handlerForShard(senderShard uint32, node string) (*rpc.HTTPMessenger, error) {
-	s, err := sharding.Structure(node)
-	if err != nil {
-		return nil, err
+// handlerForNodes pools the nodes when there are several, checking them up front so
+// nodes found down are tried last, a single node is used as is. Either way calls are
+// retried within --rate-limit
+func handlerForNodes(nodes []string) rpc.T {
+	switch {
+	case len(nodes) > 1:
+		pool := rpc.NewPoolHandler(nodes).WithRoundRobin(roundRobin).WithRateLimits(rateLimits).
+			WithRetryPolicy(rpc.DefaultRetryPolicy)
+		ctx, cancel := context.WithTimeout(context.Background(), poolHealthCheckTimeout)
+		defer cancel()
+		// With no node answering every one stays in play, the call reports the failure
+		pool.HealthCheck(ctx)
+		return pool
+	case len(nodes) == 1 && rpc.IsWebSocket(nodes[0]):
+		return rpc.NewWSHandler(nodes[0]).WithRetryPolicy(rpc.DefaultRetryPolicy).
+			WithRateLimiter(rateLimits.For(nodes[0])).WithDropObserver(reportDrop)
+	case len(nodes) == 1:
+		return rpc.NewHTTPHandler(nodes[0]).WithRetryPolicy(rpc.DefaultRetryPolicy).
+			WithRateLimiter(rateLimits.For(nodes[0]))
+	default:
+		return rpc.NewPoolHandler(nodes)
 	}
+}
 
-	for _, shard := range s {
-		if uint32(shard.ShardID) == senderShard {
-			return rpc.NewHTTPHandler(shard.HTTP), nil
-		}
+// reportDrop tells of lost WebSocket connections on stderr while debugging RPC,
+// stdout is left to the JSON output
+func reportDrop(node string, err error) {
+	if common.DebugRPC {
+		fmt.Fprintf(os.Stderr, "URL: %s, connection dropped: %s\n\n", node, err)
 	}
+}
 
+// dialNode is the rpc.Dialer of commands reaching the endpoints of every shard
+func dialNode(n string) rpc.T {
+	return handlerForNodes([]string{n})
+}
+
+// cachedHandler keeps the replies of messenger that cannot change for the run, on disk with --disk-cache
+func cachedHandler(n string, messenger rpc.T) rpc.T {
+	cached := rpc.NewCachingHandler(n, messenger, rpcCacheSize)
+	if diskCache {
+		cached.WithDiskCache(store.RPCCacheLocation(), rpcDiskCacheSize)
+	}
+	return cached
+}
+
+// shardRouter discovers the shards of the network of --node, asking every node listed
+func shardRouter(ctx context.Context) (*sharding.Router, error) {
+	return sharding.NewRouterUsing(ctx, func(n string) rpc.T {
+		return cachedHandler(n, handlerForNodes(rpc.SplitNodes(n)))
+	}, node)
+}
+
+// gasFlags registers the gas overrides of the commands building a transfer
+func gasFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64Var(&transferGasPrice, "gas-price", 0, "gas price to pay in nano, suggested by the node when not given")
+	cmd.Flags().Uint64Var(&gasLimit, "gas-limit", 0, "gas limit, estimated by the node when not given")
+	cmd.Flags().Uint64Var(&gasMargin, "gas-margin",
+		transaction.DefaultGasMarginPercent, "percent added to the gas estimated by the node",
+	)
+}
+
+// dataFlags registers the payload options of the commands building a transfer
+func dataFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&inputData, "data", "", "hex encoded payload, e.g. a contract call")
+	cmd.Flags().StringVar(&inputDataFile, "data-file", "", "file holding the hex encoded payload, surrounding whitespace is ignored")
+}
+
+// transferData is the payload given by --data or --data-file, both hex encoded, nil when neither is
+func transferData() ([]byte, error) {
+	switch {
+	case inputData != "" && inputDataFile != "":
+		return nil, fmt.Errorf("--data and --data-file cannot be combined")
+	case inputData != "":
+		return decodeData("--data", inputData)
+	case inputDataFile != "":
+		content, err := ioutil.ReadFile(inputDataFile)
+		if err != nil {
+			return nil, err
+		}
+		return decodeData("--data-file", string(content))
+	}
 	return nil, nil
 }
 
+func decodeData(flag, encoded string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(encoded), "0x"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not hex", flag)
+	}
+	return data, nil
+}
+
 func opts(ctlr *transaction.Controller) {
 	if dryRun {
 		ctlr.Behavior.DryRun = true
@@ -53,6 +145,8 @@ func opts(ctlr *transaction.Controller) {
 	if confirmWait > 0 {
 		ctlr.Behavior.ConfirmationWaitTime = confirmWait
 	}
+	ctlr.Behavior.GasLimit = gasLimit
+	ctlr.Behavior.GasMarginPercent = gasMargin
 }
 
 func init() {
@@ -64,34 +158,47 @@ Create a transaction, sign it, and send off to the Harmony blockchain
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			from := fromAddress.String()
-			s, err := sharding.Structure(node)
+			data, err := transferData()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
 			if err != nil {
 				return err
 			}
-			err = validation.ValidShardIDs(fromShardID, toShardID, uint32(len(s)))
+			defer router.Close()
+			err = validation.ValidShardIDs(fromShardID, toShardID, router.ShardCount())
 			if err != nil {
 				return err
 			}
-			networkHandler, err := handlerForShard(fromShardID, node)
+			networkHandler, err := router.ForShard(fromShardID)
 			if err != nil {
 				return err
 			}
 			var ctrlr *transaction.Controller
 			if useLedgerWallet {
 				account := accounts.Account{Address: address.Parse(from)}
-				ctrlr = transaction.NewController(networkHandler, nil, &account, *chainName.chainID, opts)
+				ctrlr = transaction.NewController(
+					networkHandler, nil, &account, *chainName.chainID, opts,
+					transaction.WithContext(ctx), transaction.WithNonceManager(nonces),
+				)
 			} else {
 				ks, acct, err := store.UnlockedKeystore(from, unlockP)
 				if err != nil {
 					return err
 				}
-				ctrlr = transaction.NewController(networkHandler, ks, acct, *chainName.chainID, opts)
+				ctrlr = transaction.NewController(
+					networkHandler, ks, acct, *chainName.chainID, opts,
+					transaction.WithContext(ctx), transaction.WithNonceManager(nonces),
+				)
 			}
 
 			if transactionFailure := ctrlr.ExecuteTransaction(
 				toAddress.String(),
-				"",
-				amount, gasPrice,
+				data,
+				amount.Atto(), transferGasPrice,
 				int(fromShardID),
 				int(toShardID),
 			); transactionFailure != nil {
@@ -114,8 +221,9 @@ Create a transaction, sign it, and send off to the Harmony blockchain
 	cmdTransfer.Flags().Var(&fromAddress, "from", "sender's one address, keystore must exist locally")
 	cmdTransfer.Flags().Var(&toAddress, "to", "the destination one address")
 	cmdTransfer.Flags().BoolVar(&dryRun, "dry-run", false, "do not send signed transaction")
-	cmdTransfer.Flags().Float64Var(&amount, "amount", 0.0, "amount")
-	cmdTransfer.Flags().Int64Var(&gasPrice, "gas-price", 1, "gas price to pay")
+	cmdTransfer.Flags().Var(&amount, "amount", "amount in ONE, exact up to 18 decimals")
+	gasFlags(cmdTransfer)
+	dataFlags(cmdTransfer)
 	cmdTransfer.Flags().Uint32Var(&fromShardID, "from-shard", 0, "source shard id")
 	cmdTransfer.Flags().Uint32Var(&toShardID, "to-shard", 0, "target shard id")
 	cmdTransfer.Flags().Var(&chainName, "chain-id", "what chain ID to target")
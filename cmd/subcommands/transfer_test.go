@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestHandlerForNodesChecksThePool(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := mocknode.New(common.Chain.TestNet)
+	defer up.Close()
+
+	pool, ok := handlerForNodes([]string{down.URL, up.URL}).(*rpc.PoolMessenger)
+	if !ok {
+		t.Fatal("several nodes were not pooled")
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0] != up.URL {
+		t.Errorf("expected only %s healthy before the first call, got %v", up.URL, healthy)
+	}
+}
+
+func TestTransferData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "payload")
+	if err := ioutil.WriteFile(file, []byte("0xa9059cbb\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { inputData, inputDataFile = "", "" }()
+	cases := []struct {
+		data, dataFile string
+		payload        []byte
+		fails          bool
+	}{
+		{"", "", nil, false},
+		{"0xa9059cbb", "", []byte{0xa9, 0x05, 0x9c, 0xbb}, false},
+		{"a9059cbb", "", []byte{0xa9, 0x05, 0x9c, 0xbb}, false},
+		{"", file, []byte{0xa9, 0x05, 0x9c, 0xbb}, false},
+		{"hello", "", nil, true},
+		{"0x01", file, nil, true},
+		{"", filepath.Join(dir, "missing"), nil, true},
+	}
+	for _, c := range cases {
+		inputData, inputDataFile = c.data, c.dataFile
+		payload, err := transferData()
+		if (err != nil) != c.fails || !bytes.Equal(payload, c.payload) {
+			t.Errorf("--data %q --data-file %q: got %x, %v", c.data, c.dataFile, payload, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/store"
+	"github.com/harmony-one/go-sdk/pkg/transaction"
+	"github.com/harmony-one/go-sdk/pkg/validation"
+	"github.com/harmony-one/harmony/accounts"
+	"github.com/spf13/cobra"
+)
+
+var txFile string
+
+// writeTxFile saves what a tx step produced to --file, or prints it when not given
+func writeTxFile(produced interface{ Save(string) error }) error {
+	if txFile == "" {
+		fmt.Println(common.ToJSONUnsafe(produced, !noPrettyOutput))
+		return nil
+	}
+	return produced.Save(txFile)
+}
+
+func init() {
+	cmdTx := &cobra.Command{
+		Use:   "tx",
+		Short: "Build, sign and send a transaction as separate steps",
+		Long: `
+Split a transfer in three steps so the keys never have to be on a machine that
+is online: build reads the nonce from the node and writes an unsigned
+transaction, sign needs nothing but the keystore or Ledger and writes a signed
+one, send broadcasts it. Both files are JSON and carry a version
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmdBuild := &cobra.Command{
+		Use:   "build",
+		Short: "Write an unsigned transaction with its nonce and gas taken from the node",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := transferData()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			if err := validation.ValidShardIDs(fromShardID, toShardID, router.ShardCount()); err != nil {
+				return err
+			}
+			networkHandler, err := router.ForShard(fromShardID)
+			if err != nil {
+				return err
+			}
+			account := accounts.Account{Address: address.Parse(fromAddress.String())}
+			unsigned, err := transaction.NewController(
+				networkHandler, nil, &account, *chainName.chainID, opts,
+				transaction.WithContext(ctx), transaction.WithNonceManager(nonces),
+			).BuildTransaction(toAddress.String(), data, amount.Atto(), transferGasPrice, int(fromShardID), int(toShardID))
+			if err != nil {
+				return err
+			}
+			return writeTxFile(unsigned)
+		},
+	}
+	cmdBuild.Flags().Var(&fromAddress, "from", "sender's one address, its key is not needed")
+	cmdBuild.Flags().Var(&toAddress, "to", "the destination one address")
+	cmdBuild.Flags().Var(&amount, "amount", "amount in ONE, exact up to 18 decimals")
+	gasFlags(cmdBuild)
+	dataFlags(cmdBuild)
+	cmdBuild.Flags().Uint32Var(&fromShardID, "from-shard", 0, "source shard id")
+	cmdBuild.Flags().Uint32Var(&toShardID, "to-shard", 0, "target shard id")
+	cmdBuild.Flags().Var(&chainName, "chain-id", "what chain ID to target")
+	cmdBuild.Flags().StringVar(&txFile, "file", "", "where to write the unsigned transaction, printed if not given")
+	for _, flagName := range [...]string{"from", "to", "amount", "from-shard", "to-shard"} {
+		cmdBuild.MarkFlagRequired(flagName)
+	}
+
+	cmdSign := &cobra.Command{
+		Use:   "sign <unsigned-file>",
+		Short: "Sign a transaction written by build, no node is contacted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unsigned, err := transaction.LoadUnsignedTransaction(args[0])
+			if err != nil {
+				return err
+			}
+			chain, err := common.StringToChainID(unsigned.ChainID)
+			if err != nil {
+				return err
+			}
+			var ctrlr *transaction.Controller
+			if useLedgerWallet {
+				account := accounts.Account{Address: address.Parse(unsigned.From)}
+				ctrlr = transaction.NewController(nil, nil, &account, *chain, opts)
+			} else {
+				ks, acct, err := store.UnlockedKeystore(unsigned.From, unlockP)
+				if err != nil {
+					return err
+				}
+				ctrlr = transaction.NewController(nil, ks, acct, *chain, opts)
+			}
+			signed, err := ctrlr.SignTransaction(unsigned)
+			if err != nil {
+				return err
+			}
+			return writeTxFile(signed)
+		},
+	}
+	cmdSign.Flags().StringVar(&unlockP,
+		"passphrase", common.DefaultPassphrase,
+		"passphrase to unlock sender's keystore",
+	)
+	cmdSign.Flags().StringVar(&txFile, "file", "", "where to write the signed transaction, printed if not given")
+
+	cmdSend := &cobra.Command{
+		Use:   "send <signed-file or 0x raw transaction>",
+		Short: "Broadcast a transaction written by sign, or given as raw hex",
+		Args:  cobra.ExactArgs(1),
+		Long: `
+Send a signed transaction to the shard it was signed for. A raw hex transaction
+carries no chain name, it is checked against --chain-id instead
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			raw, chain := args[0], chainName.chainID
+			if !strings.HasPrefix(raw, "0x") {
+				signed, err := transaction.LoadSignedTransaction(raw)
+				if err != nil {
+					return err
+				}
+				if chain, err = common.StringToChainID(signed.ChainID); err != nil {
+					return err
+				}
+				raw = signed.Raw
+			}
+			tx, err := transaction.DecodeRawTransaction(raw)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			router, err := shardRouter(ctx)
+			if err != nil {
+				return err
+			}
+			defer router.Close()
+			networkHandler, err := router.ForShard(tx.ShardID())
+			if err != nil {
+				return err
+			}
+			ctrlr := transaction.NewController(networkHandler, nil, nil, *chain, opts, transaction.WithContext(ctx))
+			if err := ctrlr.SendRawTransaction(raw); err != nil {
+				return err
+			}
+			if confirmWait > 0 {
+				fmt.Println(common.ToJSONUnsafe(ctrlr.Receipt(), !noPrettyOutput))
+			} else {
+				fmt.Println(fmt.Sprintf(`{"transaction-receipt":"%s"}`, *ctrlr.ReceiptHash()))
+			}
+			return nil
+		},
+	}
+	cmdSend.Flags().Var(&chainName, "chain-id", "what chain ID a raw transaction is for")
+	cmdSend.Flags().Uint32Var(&confirmWait, "wait-for-confirm", 0, "only waits if non-zero value, in seconds")
+
+	cmdTx.AddCommand(cmdBuild, cmdSign, cmdSend)
+	RootCmd.AddCommand(cmdTx)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	color "github.com/fatih/color"
 )
@@ -9,6 +10,11 @@ import (
 const (
 	hmyDocsDir      = "hmy-docs"
 	defaultNodeAddr = "http://localhost:9500"
+	rpcCacheSize    = 256
+	// rpcDiskCacheSize is how many replies --disk-cache keeps
+	rpcDiskCacheSize = 4096
+	// poolHealthCheckTimeout bounds the check of the nodes given to --node before the first call
+	poolHealthCheckTimeout = 3 * time.Second
 )
 
 var (
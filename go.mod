@@ -11,6 +11,7 @@ require (
 	github.com/elastic/gosigar v0.10.5 // indirect
 	github.com/ethereum/go-ethereum v1.8.27
 	github.com/fatih/color v1.7.0
+	github.com/gorilla/websocket v1.4.1
 	github.com/harmony-one/bls v0.0.5
 	github.com/harmony-one/harmony v0.0.0-20191106012056-10f5d6274891
 	github.com/harmony-one/vdf v1.0.0 // indirect
@@ -1,11 +1,107 @@
 package common
 
 import (
+	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/harmony-one/harmony/common/denominations"
 )
 
+// attoDecimals is how many decimals of ONE an atto is
+const attoDecimals = 18
+
 func NormalizeAmount(value *big.Int) *big.Int {
 	return value.Div(value, big.NewInt(denominations.Nano))
 }
+
+// Amount is a number of ONE held exactly in atto, it is read and printed as a
+// decimal number of ONE such as 1234567.123456789012345678. The zero value is 0
+type Amount big.Int
+
+// NewAmount reads a decimal number of ONE, more than 18 decimals cannot be
+// expressed in atto and are refused rather than rounded
+func NewAmount(one string) (*Amount, error) {
+	atto, err := ParseAmount(one)
+	if err != nil {
+		return nil, err
+	}
+	return (*Amount)(atto), nil
+}
+
+// Atto is the amount in atto, a copy the caller may change
+func (a *Amount) Atto() *big.Int {
+	return new(big.Int).Set((*big.Int)(a))
+}
+
+func (a *Amount) String() string {
+	return FormatAmount((*big.Int)(a))
+}
+
+// Set reads one as NewAmount does, so an Amount can serve as a flag
+func (a *Amount) Set(one string) error {
+	atto, err := ParseAmount(one)
+	if err != nil {
+		return err
+	}
+	(*big.Int)(a).Set(atto)
+	return nil
+}
+
+func (a *Amount) Type() string {
+	return "amount"
+}
+
+// MarshalText writes the amount as decimal ONE
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText reads decimal ONE
+func (a *Amount) UnmarshalText(text []byte) error {
+	return a.Set(string(text))
+}
+
+// ParseAmount converts a decimal number of ONE into atto without going through floating point
+func ParseAmount(one string) (*big.Int, error) {
+	whole, fraction := strings.TrimSpace(one), ""
+	if i := strings.Index(whole, "."); i >= 0 {
+		whole, fraction = whole[:i], whole[i+1:]
+	}
+	if whole == "" && fraction == "" || !digitsOnly(whole) || !digitsOnly(fraction) {
+		return nil, fmt.Errorf("invalid amount %q, expected a decimal number of ONE", one)
+	}
+	if len(fraction) > attoDecimals {
+		return nil, fmt.Errorf("invalid amount %q, at most %d decimals fit in atto", one, attoDecimals)
+	}
+	atto, _ := new(big.Int).SetString(
+		"0"+whole+fraction+strings.Repeat("0", attoDecimals-len(fraction)), 10,
+	)
+	return atto, nil
+}
+
+// FormatAmount writes atto as an exact decimal number of ONE, without trailing zeros
+func FormatAmount(atto *big.Int) string {
+	digits := new(big.Int).Abs(atto).String()
+	if len(digits) <= attoDecimals {
+		digits = strings.Repeat("0", attoDecimals-len(digits)+1) + digits
+	}
+	whole := digits[:len(digits)-attoDecimals]
+	fraction := strings.TrimRight(digits[len(digits)-attoDecimals:], "0")
+	if atto.Sign() < 0 {
+		whole = "-" + whole
+	}
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
+
+func digitsOnly(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
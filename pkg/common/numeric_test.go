@@ -0,0 +1,30 @@
+package common
+
+import "testing"
+
+func TestParseAmountIsExact(t *testing.T) {
+	for one, atto := range map[string]string{
+		"1234567.123456789012345678": "1234567123456789012345678",
+		"0.000000000000000001":       "1",
+		"10":                         "10000000000000000000",
+		".5":                         "500000000000000000",
+		"99999999999999999999":       "99999999999999999999000000000000000000",
+	} {
+		parsed, err := ParseAmount(one)
+		if err != nil {
+			t.Errorf("%s: %v", one, err)
+			continue
+		}
+		if parsed.String() != atto {
+			t.Errorf("%s read as %s atto, expected %s", one, parsed, atto)
+		}
+		if back := FormatAmount(parsed); back != one && "0"+one != back {
+			t.Errorf("%s atto written as %s", atto, back)
+		}
+	}
+	for _, invalid := range []string{"", ".", "-1", "1e18", "1.0000000000000000001", "1,5", "0x10"} {
+		if _, err := ParseAmount(invalid); err == nil {
+			t.Errorf("%q was accepted", invalid)
+		}
+	}
+}
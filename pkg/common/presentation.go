@@ -3,10 +3,7 @@ package common
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"math/big"
-
-	"github.com/harmony-one/harmony/common/denominations"
 )
 
 func JSONPrettyFormat(in string) string {
@@ -30,42 +27,7 @@ func ToJSONUnsafe(payload interface{}, pretty bool) string {
 	return string(j)
 }
 
+// ConvertBalanceIntoReadableFormat writes a balance in atto as an exact decimal number of ONE
 func ConvertBalanceIntoReadableFormat(balance *big.Int) string {
-	balance = balance.Div(balance, big.NewInt(denominations.Nano))
-	strBalance := fmt.Sprintf("%d", balance.Uint64())
-
-	bytes := []byte(strBalance)
-	hasDecimal := false
-	for i := 0; i < 11; i++ {
-		if len(bytes)-1-i < 0 {
-			bytes = append([]byte{'0'}, bytes...)
-		}
-		if bytes[len(bytes)-1-i] != '0' && i < 9 {
-			hasDecimal = true
-		}
-		if i == 9 {
-			newBytes := append([]byte{'.'}, bytes[len(bytes)-i:]...)
-			bytes = append(bytes[:len(bytes)-i], newBytes...)
-		}
-	}
-	zerosToRemove := 0
-	for i := 0; i < len(bytes); i++ {
-		if hasDecimal {
-			if bytes[len(bytes)-1-i] == '0' {
-				bytes = bytes[:len(bytes)-1-i]
-				i--
-			} else {
-				break
-			}
-		} else {
-			if zerosToRemove < 5 {
-				bytes = bytes[:len(bytes)-1-i]
-				i--
-				zerosToRemove++
-			} else {
-				break
-			}
-		}
-	}
-	return string(bytes)
+	return FormatAmount(balance)
 }
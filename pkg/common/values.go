@@ -10,6 +10,7 @@ import (
 const (
 	DefaultConfigDirName               = ".hmy_cli"
 	DefaultConfigAccountAliasesDirName = "account-keys"
+	DefaultConfigRPCCacheDirName       = "rpc-cache"
 	DefaultPassphrase                  = "harmony-one"
 	JSONRPCVersion                     = "2.0"
 	Secp256k1PrivateKeyBytesLength     = 32
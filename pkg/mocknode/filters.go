@@ -0,0 +1,155 @@
+package mocknode
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+// errFilterNotFound is what a node answers for an unknown or expired filter, -32000 is its generic server error
+var errFilterNotFound = &rpc.RPCError{Code: -32000, Message: "filter not found"}
+
+// filter is an installed filter, cursor is the first log or block it has not reported yet
+type filter struct {
+	method string
+	query  rpc.FilterQuery
+	cursor int
+}
+
+// EmitLog records log in a block of its own, as if a contract emitted it
+func (N *Node) EmitLog(log rpc.Log) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	block := N.mine()
+	log.BlockNumber, log.BlockHash = block.Number, block.Hash
+	N.logs = append(N.logs, &log)
+}
+
+// ExpireFilters forgets every installed filter, as the node does with filters not polled for a while
+func (N *Node) ExpireFilters() {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	N.filters = make(map[string]*filter)
+}
+
+func (N *Node) filterHandlers() map[string]Handler {
+	install := func(method string) Handler {
+		return func(params []json.RawMessage) (interface{}, error) {
+			installed := &filter{method: method}
+			if method == rpc.Method.NewFilter {
+				if err := param(params, 0, &installed.query); err != nil {
+					return nil, err
+				}
+			}
+			N.lock.Lock()
+			defer N.lock.Unlock()
+			installed.cursor = len(N.logs)
+			if method == rpc.Method.NewBlockFilter {
+				installed.cursor = len(N.blocks)
+			}
+			N.nextFilter++
+			id := hexutil.EncodeUint64(N.nextFilter)
+			N.filters[id] = installed
+			return id, nil
+		}
+	}
+	return map[string]Handler{
+		rpc.Method.NewFilter:                   install(rpc.Method.NewFilter),
+		rpc.Method.NewBlockFilter:              install(rpc.Method.NewBlockFilter),
+		rpc.Method.NewPendingTransactionFilter: install(rpc.Method.NewPendingTransactionFilter),
+		rpc.Method.GetFilterChanges: func(params []json.RawMessage) (interface{}, error) {
+			id, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			N.lock.Lock()
+			installed, ok := N.filters[id]
+			N.lock.Unlock()
+			if !ok {
+				return nil, errFilterNotFound
+			}
+			return N.locked(func() interface{} { return N.changes(installed) })
+		},
+		rpc.Method.GetPastLogs: func(params []json.RawMessage) (interface{}, error) {
+			q := rpc.FilterQuery{}
+			if err := param(params, 0, &q); err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				from, to := N.blockRange(q)
+				logs := []*rpc.Log{}
+				for _, log := range N.logs {
+					if uint64(log.BlockNumber) >= from && uint64(log.BlockNumber) <= to && matches(q, log) {
+						logs = append(logs, log)
+					}
+				}
+				return logs
+			})
+		},
+	}
+}
+
+// changes is what installed missed since it was last polled, caller must hold N.lock
+func (N *Node) changes(installed *filter) interface{} {
+	switch installed.method {
+	case rpc.Method.NewFilter:
+		logs := []*rpc.Log{}
+		for _, log := range N.logs[installed.cursor:] {
+			if matches(installed.query, log) {
+				logs = append(logs, log)
+			}
+		}
+		installed.cursor = len(N.logs)
+		return logs
+	case rpc.Method.NewBlockFilter:
+		hashes := []string{}
+		for _, block := range N.blocks[installed.cursor:] {
+			hashes = append(hashes, block.Hash)
+		}
+		installed.cursor = len(N.blocks)
+		return hashes
+	}
+	return []string{}
+}
+
+// blockRange reads the bounds of q, both default to the latest block
+func (N *Node) blockRange(q rpc.FilterQuery) (uint64, uint64) {
+	bound := func(arg string) uint64 {
+		if arg == "" {
+			arg = "latest"
+		}
+		if block := N.blockByNumber(arg); block != nil {
+			return uint64(block.Number)
+		}
+		return uint64(N.head().Number)
+	}
+	return bound(q.FromBlock), bound(q.ToBlock)
+}
+
+// matches applies the address and topic criteria of q to log
+func matches(q rpc.FilterQuery, log *rpc.Log) bool {
+	if len(q.Address) > 0 && !containsFold(q.Address, log.Address) {
+		return false
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) || !containsFold(alternatives, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
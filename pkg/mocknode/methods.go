@@ -0,0 +1,302 @@
+package mocknode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+const mockGasPrice = 1
+
+var notSupported = &rpc.RPCError{Code: rpc.ErrMethodNotFound.Code, Message: "not supported by the mock node"}
+
+// param decodes the i-th parameter into v, a missing parameter is an error
+func param(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return &rpc.RPCError{Code: rpc.ErrInvalidParams.Code, Message: fmt.Sprintf("missing parameter %d", i+1)}
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return &rpc.RPCError{Code: rpc.ErrInvalidParams.Code, Message: err.Error()}
+	}
+	return nil
+}
+
+func stringParam(params []json.RawMessage, i int) (string, error) {
+	s := ""
+	err := param(params, i, &s)
+	return s, err
+}
+
+// locked runs read under the node lock and encodes its result right away,
+// so nothing of the state escapes the lock
+func (N *Node) locked(read func() interface{}) (interface{}, error) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	result := read()
+	if result == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(result)
+	return json.RawMessage(encoded), err
+}
+
+func constant(result interface{}) Handler {
+	return func([]json.RawMessage) (interface{}, error) {
+		return result, nil
+	}
+}
+
+func failing(err error) Handler {
+	return func([]json.RawMessage) (interface{}, error) {
+		return nil, err
+	}
+}
+
+func (N *Node) defaultHandlers() map[string]Handler {
+	handlers := map[string]Handler{
+		rpc.Method.GetShardingStructure: func([]json.RawMessage) (interface{}, error) {
+			return N.network.routes, nil
+		},
+		rpc.Method.GetNodeMetadata: func([]json.RawMessage) (interface{}, error) {
+			return rpc.NodeMetadata{
+				Version:     "mocknode",
+				NetworkType: N.network.chain.Name,
+				ChainID:     N.network.chain.Value.String(),
+				IsLeader:    true,
+				ShardID:     N.ShardID,
+				NodeRole:    "Validator",
+			}, nil
+		},
+		rpc.Method.GetLatestBlockHeader: func([]json.RawMessage) (interface{}, error) {
+			return N.locked(func() interface{} {
+				head := N.head()
+				return map[string]interface{}{
+					"blockHash":   head.Hash,
+					"blockNumber": uint64(head.Number),
+					"shardID":     N.ShardID,
+					"unixtime":    uint64(head.Timestamp),
+				}
+			})
+		},
+		rpc.Method.GetBlockByHash: func(params []json.RawMessage) (interface{}, error) {
+			hash, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return blockResult(N.blockByHash(hash), params) })
+		},
+		rpc.Method.GetBlockByNumber: func(params []json.RawMessage) (interface{}, error) {
+			number, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return blockResult(N.blockByNumber(number), params) })
+		},
+		rpc.Method.GetBlockTransactionCountByHash: func(params []json.RawMessage) (interface{}, error) {
+			hash, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return transactionCount(N.blockByHash(hash)) })
+		},
+		rpc.Method.GetBlockTransactionCountByNumber: func(params []json.RawMessage) (interface{}, error) {
+			number, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return transactionCount(N.blockByNumber(number)) })
+		},
+		rpc.Method.GetTransactionByBlockHashAndIndex: func(params []json.RawMessage) (interface{}, error) {
+			hash, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return transactionAt(N.blockByHash(hash), params) })
+		},
+		rpc.Method.GetTransactionByBlockNumberAndIndex: func(params []json.RawMessage) (interface{}, error) {
+			number, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} { return transactionAt(N.blockByNumber(number), params) })
+		},
+		rpc.Method.GetTransactionByHash: func(params []json.RawMessage) (interface{}, error) {
+			hash, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				if tx, ok := N.transactions[hash]; ok {
+					return tx
+				}
+				return nil
+			})
+		},
+		rpc.Method.GetTransactionReceipt: func(params []json.RawMessage) (interface{}, error) {
+			hash, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				if receipt, ok := N.receipts[hash]; ok {
+					return receipt
+				}
+				return nil
+			})
+		},
+		rpc.Method.GetBalance: func(params []json.RawMessage) (interface{}, error) {
+			addr, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				return (*hexutil.Big)(N.balanceOf(address.Parse(addr)))
+			})
+		},
+		rpc.Method.GetTransactionCount: func(params []json.RawMessage) (interface{}, error) {
+			addr, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				return hexutil.Uint64(N.nonces[address.Parse(addr)])
+			})
+		},
+		rpc.Method.SendRawTransaction: func(params []json.RawMessage) (interface{}, error) {
+			raw, err := rawParam(params)
+			if err != nil {
+				return nil, err
+			}
+			return N.applyTransaction(raw)
+		},
+		rpc.Method.SendRawStakingTransaction: func(params []json.RawMessage) (interface{}, error) {
+			raw, err := rawParam(params)
+			if err != nil {
+				return nil, err
+			}
+			return N.applyStakingTransaction(raw)
+		},
+		rpc.Method.BlockNumber: func([]json.RawMessage) (interface{}, error) {
+			return N.locked(func() interface{} { return N.head().Number })
+		},
+		rpc.Method.GetActiveValidatorAddresses: func([]json.RawMessage) (interface{}, error) {
+			return N.locked(func() interface{} { return N.sortedValidators() })
+		},
+		rpc.Method.GetAllValidatorAddresses: func([]json.RawMessage) (interface{}, error) {
+			return N.locked(func() interface{} { return N.sortedValidators() })
+		},
+		rpc.Method.GetValidatorInformation: func(params []json.RawMessage) (interface{}, error) {
+			addr, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				if validator, ok := N.validators[address.Parse(addr)]; ok {
+					return validator
+				}
+				return nil
+			})
+		},
+		rpc.Method.GetDelegationsByValidator: func(params []json.RawMessage) (interface{}, error) {
+			addr, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			return N.locked(func() interface{} {
+				delegations := []rpc.Delegation{}
+				if validator, ok := N.validators[address.Parse(addr)]; ok {
+					delegations = append(delegations, validator.Delegations...)
+				}
+				return delegations
+			})
+		},
+		rpc.Method.GetDelegationsByDelegator: func(params []json.RawMessage) (interface{}, error) {
+			addr, err := stringParam(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			delegator := address.Parse(addr)
+			return N.locked(func() interface{} {
+				delegations := []rpc.Delegation{}
+				for _, validator := range N.validators {
+					for _, delegation := range validator.Delegations {
+						if address.Parse(delegation.DelegatorAddress) == delegator {
+							delegations = append(delegations, delegation)
+						}
+					}
+				}
+				return delegations
+			})
+		},
+		rpc.Method.GetCode:         constant("0x"),
+		rpc.Method.GetStorageAt:    constant(fmt.Sprintf("0x%064x", 0)),
+		rpc.Method.Syncing:         constant(false),
+		rpc.Method.PeerCount:       constant("0x0"),
+		rpc.Method.Call:            constant("0x"),
+		rpc.Method.EstimateGas:     constant(hexutil.Uint64(21000)),
+		rpc.Method.GasPrice:        constant(hexutil.Uint64(mockGasPrice)),
+		rpc.Method.NetVersion:      constant(N.network.chain.Value.String()),
+		rpc.Method.ProtocolVersion: constant(hexutil.Uint64(1)),
+		rpc.Method.SendTransaction: failing(notSupported),
+		rpc.Method.Subscribe:       failing(notSupported),
+		rpc.Method.UnSubscribe:     failing(notSupported),
+		rpc.Method.GetWork:         failing(notSupported),
+		rpc.Method.GetProof:        failing(notSupported),
+	}
+	for method, handler := range N.filterHandlers() {
+		handlers[method] = handler
+	}
+	return handlers
+}
+
+func rawParam(params []json.RawMessage) ([]byte, error) {
+	encoded, err := stringParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hexutil.Decode(encoded)
+	if err != nil {
+		return nil, &rpc.RPCError{Code: rpc.ErrInvalidParams.Code, Message: err.Error()}
+	}
+	return raw, nil
+}
+
+// blockResult lists only transaction hashes unless the second parameter asks for full transactions
+func blockResult(block *rpc.Block, params []json.RawMessage) interface{} {
+	if block == nil {
+		return nil
+	}
+	fullTx := false
+	param(params, 1, &fullTx)
+	if fullTx {
+		return block
+	}
+	hashes := make([]string, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		hashes[i] = tx.Hash
+	}
+	brief := map[string]interface{}{}
+	encoded, _ := json.Marshal(block)
+	json.Unmarshal(encoded, &brief)
+	brief["transactions"] = hashes
+	return brief
+}
+
+func transactionCount(block *rpc.Block) interface{} {
+	if block == nil {
+		return nil
+	}
+	return hexutil.Uint64(len(block.Transactions))
+}
+
+func transactionAt(block *rpc.Block, params []json.RawMessage) interface{} {
+	index := hexutil.Uint64(0)
+	if block == nil || param(params, 1, &index) != nil || int(index) >= len(block.Transactions) {
+		return nil
+	}
+	return block.Transactions[index]
+}
@@ -0,0 +1,250 @@
+// Package mocknode runs in-process Harmony nodes over HTTP against in-memory
+// state, so code built on pkg/rpc can be exercised without a network
+package mocknode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
+)
+
+// Handler answers one JSON-RPC method, an *rpc.RPCError is sent back as is and
+// any other error as an internal error
+type Handler func(params []json.RawMessage) (interface{}, error)
+
+// Network is a set of mock nodes, one per shard, sharing a chain and sharding structure
+type Network struct {
+	Shards []*Node
+	chain  common.ChainID
+	routes []sharding.RPCRoutes
+}
+
+// Node is one shard of a Network, serving JSON-RPC at URL
+type Node struct {
+	URL      string
+	ShardID  uint32
+	network  *Network
+	server   *httptest.Server
+	lock     sync.Mutex
+	handlers map[string]Handler
+	state
+}
+
+// NewNetwork starts a node for each of shards shards on free local ports, call Close when done
+func NewNetwork(shards int, chain common.ChainID) *Network {
+	return startNetwork(make([]net.Listener, shards), chain)
+}
+
+// NewNetworkAt starts one node per listen address, e.g. localhost:9500 for shard 0
+func NewNetworkAt(chain common.ChainID, addrs ...string) (*Network, error) {
+	listeners := make([]net.Listener, len(addrs))
+	for i, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners[:i] {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners[i] = listener
+	}
+	return startNetwork(listeners, chain), nil
+}
+
+// startNetwork serves shard i on listeners[i], a nil listener picks a free port
+func startNetwork(listeners []net.Listener, chain common.ChainID) *Network {
+	network := &Network{chain: chain}
+	for i, listener := range listeners {
+		node := &Node{ShardID: uint32(i), network: network, state: newState()}
+		node.handlers = node.defaultHandlers()
+		node.server = httptest.NewUnstartedServer(node)
+		if listener != nil {
+			node.server.Listener.Close()
+			node.server.Listener = listener
+		}
+		node.server.Start()
+		node.URL = node.server.URL
+		network.Shards = append(network.Shards, node)
+		network.routes = append(network.routes, sharding.RPCRoutes{HTTP: node.URL, ShardID: i})
+	}
+	return network
+}
+
+// New starts a single shard network and returns its node
+func New(chain common.ChainID) *Node {
+	return NewNetwork(1, chain).Shards[0]
+}
+
+// Close stops every node of the network
+func (N *Network) Close() {
+	for _, node := range N.Shards {
+		node.Close()
+	}
+}
+
+// Close stops the node
+func (N *Node) Close() {
+	N.server.Close()
+}
+
+// Handle answers method with h from now on, replacing the built in behavior if any
+func (N *Node) Handle(method string, h Handler) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	N.handlers[method] = h
+}
+
+// Handler is how method is answered now, nil for an unknown method, so Handle can wrap it
+func (N *Node) Handler(method string) Handler {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	return N.handlers[method]
+}
+
+type request struct {
+	ID     interface{}       `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *errorBody  `json:"error,omitempty"`
+}
+
+type errorBody struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ServeHTTP answers single calls and batches
+func (N *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	batch := []request{}
+	if json.Unmarshal(body, &batch) == nil {
+		responses := make([]response, len(batch))
+		for i, call := range batch {
+			responses[i] = N.answer(call)
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+	call := request{}
+	if err := json.Unmarshal(body, &call); err != nil {
+		json.NewEncoder(w).Encode(response{JSONRPC: common.JSONRPCVersion, Error: &errorBody{
+			Code: rpc.ErrParseError.Code, Message: err.Error(),
+		}})
+		return
+	}
+	json.NewEncoder(w).Encode(N.answer(call))
+}
+
+func (N *Node) answer(call request) response {
+	reply := response{JSONRPC: common.JSONRPCVersion, ID: call.ID}
+	N.lock.Lock()
+	handler, known := N.handlers[call.Method]
+	N.lock.Unlock()
+	if !known {
+		reply.Error = &errorBody{Code: rpc.ErrMethodNotFound.Code, Message: "method " + call.Method + " not found"}
+		return reply
+	}
+	result, err := handler(call.Params)
+	switch e := err.(type) {
+	case nil:
+		reply.Result = result
+		if result == nil {
+			reply.Result = json.RawMessage("null")
+		}
+	case *rpc.RPCError:
+		reply.Error = &errorBody{Code: e.Code, Message: e.Message, Data: e.Data}
+	default:
+		reply.Error = &errorBody{Code: rpc.ErrInternalError.Code, Message: err.Error()}
+	}
+	return reply
+}
+
+// SetBalance credits addr, one or 0x form, with exactly atto
+func (N *Node) SetBalance(addr string, atto *big.Int) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	N.balances[address.Parse(addr)] = new(big.Int).Set(atto)
+}
+
+// Balance is the current balance of addr in atto
+func (N *Node) Balance(addr string) *big.Int {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	return new(big.Int).Set(N.balanceOf(address.Parse(addr)))
+}
+
+// SetNonce sets the nonce the next transaction from addr has to use
+func (N *Node) SetNonce(addr string, nonce uint64) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	N.nonces[address.Parse(addr)] = nonce
+}
+
+// Nonce is the nonce the next transaction from addr has to use
+func (N *Node) Nonce(addr string) uint64 {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	return N.nonces[address.Parse(addr)]
+}
+
+// AddValidator registers a validator, it is reported as active
+func (N *Node) AddValidator(validator rpc.ValidatorInformation) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	N.validators[address.Parse(validator.Address)] = &validator
+}
+
+// Validator is the validator registered at addr, one or 0x form, as the node holds it
+func (N *Node) Validator(addr string) (rpc.ValidatorInformation, bool) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	validator, ok := N.validators[address.Parse(addr)]
+	if !ok {
+		return rpc.ValidatorInformation{}, false
+	}
+	copied := *validator
+	copied.Delegations = append([]rpc.Delegation{}, validator.Delegations...)
+	return copied, true
+}
+
+// Receipt is the receipt of a transaction the node accepted
+func (N *Node) Receipt(hash string) (*rpc.Receipt, bool) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	receipt, ok := N.receipts[hash]
+	return receipt, ok
+}
+
+// MineTransactions records txs in a block of their own, as if other senders got them in
+func (N *Node) MineTransactions(txs ...rpc.Transaction) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	block := N.mine()
+	for i, tx := range txs {
+		index, number := hexutil.Uint64(i), block.Number
+		tx.BlockHash, tx.BlockNumber, tx.TransactionIndex = &block.Hash, &number, &index
+		block.Transactions = append(block.Transactions, tx)
+	}
+}
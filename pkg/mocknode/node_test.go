@@ -0,0 +1,74 @@
+package mocknode
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
+)
+
+func TestBalancesAcrossShards(t *testing.T) {
+	network := NewNetwork(2, common.Chain.TestNet)
+	defer network.Close()
+	alice := address.ToBech32(address.Parse("0x00000000000000000000000000000000000000a1"))
+	network.Shards[0].SetBalance(alice, big.NewInt(1e18))
+	network.Shards[1].SetBalance(alice, big.NewInt(5e17))
+
+	out, err := sharding.CheckAllShardsForAddresses(network.Shards[0].URL, []string{alice}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances := map[string][]struct {
+		Shard  int         `json:"shard"`
+		Amount json.Number `json:"amount"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &balances); err != nil {
+		t.Fatal(err)
+	}
+	got := balances[alice]
+	if len(got) != 2 {
+		t.Fatalf("expected a balance on both shards, got %s", out)
+	}
+	for i, expected := range []float64{1, 0.5} {
+		if amount, _ := got[i].Amount.Float64(); got[i].Shard != i || amount != expected {
+			t.Errorf("unexpected balance on shard %d: %s", i, out)
+		}
+	}
+}
+
+func TestClientAgainstNode(t *testing.T) {
+	node := New(common.Chain.TestNet)
+	defer node.Close()
+	alice := address.ToBech32(address.Parse("0x00000000000000000000000000000000000000a1"))
+	node.SetNonce(alice, 7)
+	node.AddValidator(rpc.ValidatorInformation{Address: alice, Name: "alice", MinSelfDelegation: big.NewInt(1)})
+	client := rpc.NewClient(rpc.NewHTTPHandler(node.URL))
+	ctx := context.Background()
+
+	// Nonces are asked for by 0x address, balances by one address
+	if nonce, err := client.GetTransactionCount(ctx, address.Parse(alice).Hex()); err != nil || nonce != 7 {
+		t.Errorf("expected nonce 7, got %d %v", nonce, err)
+	}
+	validator, err := client.GetValidatorInformation(ctx, alice)
+	if err != nil || validator.Name != "alice" {
+		t.Errorf("unexpected validator %+v %v", validator, err)
+	}
+	if _, err := client.GetTransactionReceipt(ctx, "0x01"); err != rpc.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	block, err := client.GetBlockByNumber(ctx, nil, false)
+	if err != nil || block.Number != 0 {
+		t.Errorf("unexpected genesis block %+v %v", block, err)
+	}
+	// Registered with the SDK, so it is sent, but unknown to the node
+	rpc.RegisterMethod("hmy_notAMethod", rpc.MethodSpec{})
+	_, err = rpc.NewHTTPHandler(node.URL).SendRPC("hmy_notAMethod", []interface{}{})
+	if rpcErr, ok := err.(*rpc.RPCError); !ok || !rpcErr.Is(rpc.ErrMethodNotFound) {
+		t.Errorf("expected method not found, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package mocknode
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	staking "github.com/harmony-one/harmony/staking/types"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
+)
+
+// applyStakingTransaction runs a signed staking transaction in a block of its own,
+// creating validators and moving delegations. Other directives only pay their fee
+func (N *Node) applyStakingTransaction(raw []byte) (string, error) {
+	tx := &staking.StakingTransaction{}
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return "", &rpc.RPCError{Code: rpc.ErrDeserializationError.Code, Message: err.Error()}
+	}
+	if tx.ChainID().Cmp(N.network.chain.Value) != 0 {
+		return "", &rpc.RPCError{Code: rpc.ErrIncorrectChainID.Code, Message: "invalid chain id for signer"}
+	}
+	if N.ShardID != sharding.BeaconShard {
+		return "", rejected("staking transaction sent to a shard other than the beacon shard")
+	}
+	from, err := staking.Sender(staking.NewEIP155Signer(tx.ChainID()), tx)
+	if err != nil {
+		return "", &rpc.RPCError{Code: rpc.ErrVerifyError.Code, Message: err.Error()}
+	}
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	if err := N.checkNonce(from, tx.Nonce()); err != nil {
+		return "", err
+	}
+	fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	if N.balanceOf(from).Cmp(fee) < 0 {
+		return "", rejected("insufficient funds for gas * price")
+	}
+	N.balances[from] = new(big.Int).Sub(N.balanceOf(from), fee)
+	if err := N.stake(from, tx.StakingMessage()); err != nil {
+		// Refused transactions are not charged
+		N.balances[from] = new(big.Int).Add(N.balanceOf(from), fee)
+		return "", err
+	}
+	N.nonces[from]++
+	hash := tx.Hash().Hex()
+	block := N.mine()
+	block.GasUsed = hexutil.Uint64(tx.Gas())
+	status := hexutil.Uint64(1)
+	N.receipts[hash] = &rpc.Receipt{
+		BlockHash:         block.Hash,
+		BlockNumber:       block.Number,
+		CumulativeGasUsed: hexutil.Uint64(tx.Gas()),
+		From:              address.ToBech32(from),
+		GasUsed:           hexutil.Uint64(tx.Gas()),
+		Logs:              []*rpc.Log{},
+		ShardID:           N.ShardID,
+		Status:            &status,
+		TransactionHash:   hash,
+	}
+	return hash, nil
+}
+
+// stake applies the directive of a staking transaction signed by from, caller must hold N.lock
+func (N *Node) stake(from address.T, message interface{}) error {
+	switch msg := message.(type) {
+	case *staking.CreateValidator:
+		return N.stake(from, *msg)
+	case *staking.Delegate:
+		return N.stake(from, *msg)
+	case *staking.Undelegate:
+		return N.stake(from, *msg)
+	case staking.CreateValidator:
+		if msg.ValidatorAddress != from {
+			return rejected("validator address does not match the signer")
+		}
+		if _, exists := N.validators[from]; exists {
+			return rejected("staking validator already exists")
+		}
+		if err := N.debit(from, msg.Amount); err != nil {
+			return err
+		}
+		validator := &rpc.ValidatorInformation{
+			Address:            address.ToBech32(from),
+			BLSPublicKeys:      make([]string, len(msg.SlotPubKeys)),
+			MinSelfDelegation:  msg.MinSelfDelegation,
+			MaxTotalDelegation: msg.MaxTotalDelegation,
+			Rate:               msg.Rate.String(),
+			MaxRate:            msg.MaxRate.String(),
+			MaxChangeRate:      msg.MaxChangeRate.String(),
+			CreationHeight:     new(big.Int).SetUint64(uint64(N.head().Number) + 1),
+			Delegations:        []rpc.Delegation{{DelegatorAddress: address.ToBech32(from), Amount: msg.Amount}},
+		}
+		for i, key := range msg.SlotPubKeys {
+			validator.BLSPublicKeys[i] = hex.EncodeToString(key[:])
+		}
+		if msg.Description != nil {
+			validator.Name, validator.Identity, validator.Website = msg.Name, msg.Identity, msg.Website
+			validator.SecurityContact, validator.Details = msg.SecurityContact, msg.Details
+		}
+		N.validators[from] = validator
+	case staking.Delegate:
+		if msg.DelegatorAddress != from {
+			return rejected("delegator address does not match the signer")
+		}
+		validator, ok := N.validators[msg.ValidatorAddress]
+		if !ok {
+			return rejected("staking validator does not exist")
+		}
+		if err := N.debit(from, msg.Amount); err != nil {
+			return err
+		}
+		delegation := delegationOf(validator, from)
+		delegation.Amount = new(big.Int).Add(delegation.Amount, msg.Amount)
+	case staking.Undelegate:
+		if msg.DelegatorAddress != from {
+			return rejected("delegator address does not match the signer")
+		}
+		validator, ok := N.validators[msg.ValidatorAddress]
+		if !ok {
+			return rejected("staking validator does not exist")
+		}
+		delegation := delegationOf(validator, from)
+		if delegation.Amount.Cmp(msg.Amount) < 0 {
+			return rejected("insufficient balance to undelegate")
+		}
+		delegation.Amount = new(big.Int).Sub(delegation.Amount, msg.Amount)
+		// Undelegated stake stays locked, it is never paid back here
+		delegation.Undelegations = append(delegation.Undelegations, rpc.Undelegation{
+			Amount: msg.Amount, Epoch: new(big.Int).SetUint64(uint64(N.head().Number) + 1),
+		})
+	}
+	return nil
+}
+
+// debit takes amount from the balance of addr, caller must hold N.lock
+func (N *Node) debit(addr address.T, amount *big.Int) error {
+	if amount == nil || amount.Sign() < 0 {
+		return rejected("invalid staking amount")
+	}
+	if N.balanceOf(addr).Cmp(amount) < 0 {
+		return rejected("insufficient balance to stake")
+	}
+	N.balances[addr] = new(big.Int).Sub(N.balanceOf(addr), amount)
+	return nil
+}
+
+// delegationOf is the delegation of delegator to validator, added empty if there is none
+func delegationOf(validator *rpc.ValidatorInformation, delegator address.T) *rpc.Delegation {
+	for i := range validator.Delegations {
+		if delegation := &validator.Delegations[i]; address.Parse(delegation.DelegatorAddress) == delegator {
+			if delegation.Amount == nil {
+				delegation.Amount = big.NewInt(0)
+			}
+			return delegation
+		}
+	}
+	validator.Delegations = append(validator.Delegations, rpc.Delegation{
+		DelegatorAddress: address.ToBech32(delegator), Amount: big.NewInt(0),
+	})
+	return &validator.Delegations[len(validator.Delegations)-1]
+}
+
+// rejected is the error of a transaction the node turns down
+func rejected(message string) error {
+	return &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: message}
+}
@@ -0,0 +1,209 @@
+package mocknode
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core/types"
+
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+// state is the in-memory chain of a Node, guarded by Node.lock
+type state struct {
+	balances     map[address.T]*big.Int
+	nonces       map[address.T]uint64
+	blocks       []*rpc.Block
+	transactions map[string]*rpc.Transaction
+	receipts     map[string]*rpc.Receipt
+	validators   map[address.T]*rpc.ValidatorInformation
+	logs         []*rpc.Log
+	filters      map[string]*filter
+	nextFilter   uint64
+}
+
+func newState() state {
+	s := state{
+		balances:     make(map[address.T]*big.Int),
+		nonces:       make(map[address.T]uint64),
+		transactions: make(map[string]*rpc.Transaction),
+		receipts:     make(map[string]*rpc.Receipt),
+		validators:   make(map[address.T]*rpc.ValidatorInformation),
+		filters:      make(map[string]*filter),
+	}
+	s.mine()
+	return s
+}
+
+func (s *state) balanceOf(addr address.T) *big.Int {
+	if balance, ok := s.balances[addr]; ok {
+		return balance
+	}
+	return big.NewInt(0)
+}
+
+func (s *state) head() *rpc.Block {
+	return s.blocks[len(s.blocks)-1]
+}
+
+// mine appends an empty block on top of the chain
+func (s *state) mine() *rpc.Block {
+	number := uint64(len(s.blocks))
+	block := &rpc.Block{
+		Number:       hexutil.Uint64(number),
+		Hash:         fmt.Sprintf("0x%064x", number+1),
+		ParentHash:   fmt.Sprintf("0x%064x", number),
+		GasLimit:     hexutil.Uint64(80000000),
+		Timestamp:    hexutil.Uint64(time.Now().Unix()),
+		Transactions: []rpc.Transaction{},
+		Uncles:       []string{},
+	}
+	s.blocks = append(s.blocks, block)
+	return block
+}
+
+func (s *state) blockByHash(hash string) *rpc.Block {
+	for _, block := range s.blocks {
+		if block.Hash == hash {
+			return block
+		}
+	}
+	return nil
+}
+
+func (s *state) blockByNumber(arg string) *rpc.Block {
+	if arg == "latest" || arg == "pending" {
+		return s.head()
+	}
+	if arg == "earliest" {
+		return s.blocks[0]
+	}
+	number, err := hexutil.DecodeUint64(arg)
+	if err != nil || number >= uint64(len(s.blocks)) {
+		return nil
+	}
+	return s.blocks[number]
+}
+
+func (s *state) sortedValidators() []string {
+	addrs := []string{}
+	for _, validator := range s.validators {
+		addrs = append(addrs, validator.Address)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// applyTransaction runs a signed plain transaction in a block of its own, the
+// amount of a cross shard transfer is credited on the target shard
+func (N *Node) applyTransaction(raw []byte) (string, error) {
+	tx := &types.Transaction{}
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return "", &rpc.RPCError{Code: rpc.ErrDeserializationError.Code, Message: err.Error()}
+	}
+	if tx.ChainID().Cmp(N.network.chain.Value) != 0 {
+		return "", &rpc.RPCError{Code: rpc.ErrIncorrectChainID.Code, Message: "invalid chain id for signer"}
+	}
+	if tx.ShardID() != N.ShardID {
+		return "", &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: "transaction sent to the wrong shard"}
+	}
+	from, err := types.Sender(types.NewEIP155Signer(tx.ChainID()), tx)
+	if err != nil {
+		return "", &rpc.RPCError{Code: rpc.ErrVerifyError.Code, Message: err.Error()}
+	}
+	var target *Node
+	if tx.ToShardID() != N.ShardID {
+		if int(tx.ToShardID()) >= len(N.network.Shards) {
+			return "", &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: "unknown target shard"}
+		}
+		target = N.network.Shards[tx.ToShardID()]
+	}
+	hash, err := N.commit(tx, from, target != nil)
+	if err != nil {
+		return "", err
+	}
+	// Credited once our lock is released, shards never hold each other's locks
+	if target != nil && tx.To() != nil {
+		target.lock.Lock()
+		target.balances[*tx.To()] = new(big.Int).Add(target.balanceOf(*tx.To()), tx.Value())
+		target.lock.Unlock()
+	}
+	return hash, nil
+}
+
+// commit charges the sender and records tx in a new block, the receiver is
+// only credited here when the transfer stays on this shard
+func (N *Node) commit(tx *types.Transaction, from address.T, crossShard bool) (string, error) {
+	N.lock.Lock()
+	defer N.lock.Unlock()
+	if err := N.checkNonce(from, tx.Nonce()); err != nil {
+		return "", err
+	}
+	fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	cost := new(big.Int).Add(fee, tx.Value())
+	if N.balanceOf(from).Cmp(cost) < 0 {
+		return "", &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: "insufficient funds for gas * price + value"}
+	}
+	N.balances[from] = new(big.Int).Sub(N.balanceOf(from), cost)
+	N.nonces[from]++
+	to := tx.To()
+	if to != nil && !crossShard {
+		N.balances[*to] = new(big.Int).Add(N.balanceOf(*to), tx.Value())
+	}
+
+	hash := tx.Hash().Hex()
+	block := N.mine()
+	index, number := hexutil.Uint64(0), block.Number
+	toAddr := ""
+	if to != nil {
+		toAddr = address.ToBech32(*to)
+	}
+	rpcTx := &rpc.Transaction{
+		BlockHash:        &block.Hash,
+		BlockNumber:      &number,
+		From:             address.ToBech32(from),
+		Gas:              hexutil.Uint64(tx.Gas()),
+		GasPrice:         (*hexutil.Big)(tx.GasPrice()),
+		Hash:             hash,
+		Input:            hexutil.Encode(tx.Data()),
+		Nonce:            hexutil.Uint64(tx.Nonce()),
+		To:               &toAddr,
+		TransactionIndex: &index,
+		Value:            (*hexutil.Big)(tx.Value()),
+		ShardID:          tx.ShardID(),
+		ToShardID:        tx.ToShardID(),
+	}
+	status := hexutil.Uint64(1)
+	block.Transactions = append(block.Transactions, *rpcTx)
+	block.GasUsed = hexutil.Uint64(tx.Gas())
+	N.transactions[hash] = rpcTx
+	N.receipts[hash] = &rpc.Receipt{
+		BlockHash:         block.Hash,
+		BlockNumber:       block.Number,
+		CumulativeGasUsed: hexutil.Uint64(tx.Gas()),
+		From:              rpcTx.From,
+		GasUsed:           hexutil.Uint64(tx.Gas()),
+		Logs:              []*rpc.Log{},
+		ShardID:           tx.ShardID(),
+		Status:            &status,
+		To:                toAddr,
+		TransactionHash:   hash,
+	}
+	return hash, nil
+}
+
+// checkNonce refuses any nonce of from but the next one, caller must hold N.lock
+func (s *state) checkNonce(from address.T, nonce uint64) error {
+	if nonce != s.nonces[from] {
+		return &rpc.RPCError{
+			Code:    rpc.ErrVerifyRejected.Code,
+			Message: fmt.Sprintf("invalid nonce %d, expected %d", nonce, s.nonces[from]),
+		}
+	}
+	return nil
+}
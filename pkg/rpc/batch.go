@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Call is one JSON-RPC invocation of a batch
+type Call struct {
+	Method string
+	Params []interface{}
+}
+
+// BatchReply is the outcome of one Call, exactly one of Reply and Error is set
+type BatchReply struct {
+	Reply Reply
+	Error error
+}
+
+// BatchT is implemented by messengers that can carry many calls in one round trip
+type BatchT interface {
+	SendBatch([]Call) ([]BatchReply, error)
+}
+
+// contextBatcher is a BatchT whose batches give up once their context is done
+type contextBatcher interface {
+	SendBatchContext(context.Context, []Call) ([]BatchReply, error)
+}
+
+// SendBatch sends the calls at once when the messenger supports it, one by one otherwise,
+// replies come back in the order of calls
+func SendBatch(messenger T, calls []Call) ([]BatchReply, error) {
+	return SendBatchContext(context.Background(), messenger, calls)
+}
+
+// SendBatchContext is SendBatch bounded by ctx
+func SendBatchContext(ctx context.Context, messenger T, calls []Call) ([]BatchReply, error) {
+	if batcher, ok := messenger.(contextBatcher); ok {
+		return batcher.SendBatchContext(ctx, calls)
+	}
+	if batcher, ok := messenger.(BatchT); ok {
+		type outcome struct {
+			replies []BatchReply
+			err     error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			replies, err := batcher.SendBatch(calls)
+			done <- outcome{replies, err}
+		}()
+		select {
+		case o := <-done:
+			return o.replies, o.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	replies := make([]BatchReply, len(calls))
+	for i, call := range calls {
+		replies[i].Reply, replies[i].Error = SendRPCContext(ctx, messenger, call.Method, call.Params)
+	}
+	return replies, nil
+}
+
+// BatchRequest POSTs all calls as a single JSON-RPC batch, the returned error
+// only covers the batch as a whole, per call failures are in each BatchReply
+func BatchRequest(node string, calls []Call) ([]BatchReply, error) {
+	return BatchRequestContext(context.Background(), node, calls)
+}
+
+// BatchRequestContext is BatchRequest bounded by ctx
+func BatchRequestContext(ctx context.Context, node string, calls []Call) ([]BatchReply, error) {
+	return batchVia(ctx, chain(nil, httpSender(defaultHTTPClient)), node, calls)
+}
+
+// batchVia sends calls as one batch down send
+func batchVia(ctx context.Context, send Invoker, node string, calls []Call) ([]BatchReply, error) {
+	if len(calls) == 0 {
+		return []BatchReply{}, nil
+	}
+	invocation := &Invocation{Node: node, Calls: calls, Header: http.Header{}}
+	rawReply, err := send(ctx, invocation)
+	if err != nil {
+		return nil, err
+	}
+	return liftBatchReply(rawReply, invocation.ids)
+}
+
+// liftBatchReply matches each response of a batch to its call by id, the node is free to reorder them
+func liftBatchReply(rawReply []byte, ids []string) ([]BatchReply, error) {
+	responses := []json.RawMessage{}
+	if err := json.Unmarshal(rawReply, &responses); err != nil {
+		// A rejected batch comes back as one error object instead of an array
+		if _, failure := liftReply(rawReply); failure != nil {
+			return nil, failure
+		}
+		return nil, errors.Wrap(err, "could not decode batch reply")
+	}
+	byID := make(map[string]json.RawMessage, len(responses))
+	for _, response := range responses {
+		routing := envelope{}
+		json.Unmarshal(response, &routing)
+		byID[replyID(routing.ID)] = response
+	}
+	replies := make([]BatchReply, len(ids))
+	for i, id := range ids {
+		response, ok := byID[id]
+		if !ok {
+			replies[i].Error = fmt.Errorf("no reply for call %d of batch", i)
+			continue
+		}
+		replies[i].Reply, replies[i].Error = liftReply(response)
+	}
+	return replies, nil
+}
+
+// SendBatch sends every call in one HTTP POST
+func (M *HTTPMessenger) SendBatch(calls []Call) ([]BatchReply, error) {
+	return M.SendBatchContext(context.Background(), calls)
+}
+
+// SendBatchContext is SendBatch bounded by ctx, the batch is only retried as a whole
+func (M *HTTPMessenger) SendBatchContext(ctx context.Context, calls []Call) ([]BatchReply, error) {
+	if M.invalid != nil {
+		return nil, M.invalid
+	}
+	methods := make([]string, len(calls))
+	for i, call := range calls {
+		methods[i] = call.Method
+	}
+	var replies []BatchReply
+	err := M.retry.do(ctx, methods, func() (err error) {
+		replies, err = batchVia(ctx, M.invoker(), M.node, calls)
+		return err
+	})
+	return replies, err
+}
+
+// SendBatch multiplexes the calls over the shared connection concurrently,
+// which costs no more round trips than a JSON-RPC batch would
+func (M *WSMessenger) SendBatch(calls []Call) ([]BatchReply, error) {
+	return M.SendBatchContext(context.Background(), calls)
+}
+
+// SendBatchContext is SendBatch bounded by ctx
+func (M *WSMessenger) SendBatchContext(ctx context.Context, calls []Call) ([]BatchReply, error) {
+	replies := make([]BatchReply, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call Call) {
+			defer wg.Done()
+			replies[i].Reply, replies[i].Error = M.SendRPCContext(ctx, call.Method, call.Params)
+		}(i, call)
+	}
+	wg.Wait()
+	return replies, nil
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchRequestMatchesRepliesByID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		calls := []map[string]interface{}{}
+		if err := json.Unmarshal(body, &calls); err != nil {
+			t.Error(err)
+			return
+		}
+		replies := []map[string]interface{}{}
+		// Answer in reverse order, failing unknown methods
+		for i := len(calls) - 1; i >= 0; i-- {
+			reply := map[string]interface{}{"jsonrpc": "2.0", "id": calls[i]["id"]}
+			if calls[i]["method"] == "hmy_bogus" {
+				reply["error"] = map[string]interface{}{"code": -32601, "message": "no such method"}
+			} else {
+				reply["result"] = calls[i]["method"]
+			}
+			replies = append(replies, reply)
+		}
+		json.NewEncoder(w).Encode(replies)
+	}))
+	defer server.Close()
+
+	RegisterMethod("hmy_bogus", exactly())
+	defer unregisterMethod("hmy_bogus")
+	calls := []Call{
+		{Method.BlockNumber, []interface{}{}},
+		{"hmy_bogus", []interface{}{}},
+		{Method.GasPrice, []interface{}{}},
+	}
+	replies, err := NewHTTPHandler(server.URL).SendBatch(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != len(calls) {
+		t.Fatalf("expected %d replies, got %d", len(calls), len(replies))
+	}
+	for i, call := range calls {
+		if call.Method == "hmy_bogus" {
+			if replies[i].Error == nil {
+				t.Errorf("call %d should have failed", i)
+			}
+			continue
+		}
+		if replies[i].Error != nil {
+			t.Errorf("call %d failed: %s", i, replies[i].Error)
+		} else if replies[i].Reply["result"] != call.Method {
+			t.Errorf("call %d got reply meant for %v", i, replies[i].Reply["result"])
+		}
+	}
+}
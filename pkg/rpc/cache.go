@@ -0,0 +1,321 @@
+package rpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the semi static methods a CachingMessenger keeps for a while
+var DefaultCacheTTLs = map[string]time.Duration{
+	Method.GetShardingStructure: time.Hour,
+}
+
+// CachingMessenger answers calls whose result can no longer change, like a
+// block by hash or the receipt of a mined transaction, from an in-memory LRU
+// and optionally from disk. Methods given a TTL are kept that long, everything
+// else, errors included, always goes to the node. Blocks asked for by number
+// are only kept in memory, as a node reset gives the numbers other blocks
+type CachingMessenger struct {
+	node      string
+	messenger T
+	ttls      map[string]time.Duration
+	dir       string
+	dirSize   int
+	lock      sync.Mutex
+	size      int
+	recent    *list.List
+	entries   map[string]*list.Element
+}
+
+type cacheEntry struct {
+	Key     string          `json:"key"`
+	Expires time.Time       `json:"expires"`
+	Reply   json.RawMessage `json:"reply"`
+}
+
+// NewCachingHandler caches up to size replies of messenger in memory, node
+// tells apart the entries of different networks sharing a disk cache
+func NewCachingHandler(node string, messenger T, size int) *CachingMessenger {
+	ttls := make(map[string]time.Duration, len(DefaultCacheTTLs))
+	for method, ttl := range DefaultCacheTTLs {
+		ttls[method] = ttl
+	}
+	return &CachingMessenger{
+		node:      node,
+		messenger: messenger,
+		ttls:      ttls,
+		size:      size,
+		recent:    list.New(),
+		entries:   make(map[string]*list.Element),
+	}
+}
+
+// WithDiskCache keeps up to size cached replies in dir as well, so they outlive
+// the process, the least recently written go first. Set it before first use
+func (M *CachingMessenger) WithDiskCache(dir string, size int) *CachingMessenger {
+	M.dir, M.dirSize = dir, size
+	return M
+}
+
+// WithTTL caches the replies of method for ttl, 0 stops caching it, set it before first use
+func (M *CachingMessenger) WithTTL(method string, ttl time.Duration) *CachingMessenger {
+	if ttl <= 0 {
+		delete(M.ttls, method)
+	} else {
+		M.ttls[method] = ttl
+	}
+	return M
+}
+
+func (M *CachingMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
+	return M.SendRPCContext(context.Background(), meth, params)
+}
+
+// SendRPCContext is SendRPC bounded by ctx
+func (M *CachingMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	key := M.key(meth, params)
+	if reply, ok := M.lookup(key); ok {
+		return reply, nil
+	}
+	reply, err := SendRPCContext(ctx, M.messenger, meth, params)
+	if err == nil {
+		M.keep(key, meth, params, reply)
+	}
+	return reply, err
+}
+
+func (M *CachingMessenger) SendBatch(calls []Call) ([]BatchReply, error) {
+	return M.SendBatchContext(context.Background(), calls)
+}
+
+// SendBatchContext answers what it can from the cache and sends the other calls as one batch
+func (M *CachingMessenger) SendBatchContext(ctx context.Context, calls []Call) ([]BatchReply, error) {
+	replies := make([]BatchReply, len(calls))
+	keys := make([]string, len(calls))
+	missing, missed := []Call{}, []int{}
+	for i, call := range calls {
+		keys[i] = M.key(call.Method, call.Params)
+		if reply, ok := M.lookup(keys[i]); ok {
+			replies[i].Reply = reply
+			continue
+		}
+		missing, missed = append(missing, call), append(missed, i)
+	}
+	if len(missing) == 0 {
+		return replies, nil
+	}
+	fetched, err := SendBatchContext(ctx, M.messenger, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missed {
+		replies[i] = fetched[j]
+		if fetched[j].Error == nil {
+			M.keep(keys[i], calls[i].Method, calls[i].Params, fetched[j].Reply)
+		}
+	}
+	return replies, nil
+}
+
+// Close closes the wrapped messenger when it holds connections
+func (M *CachingMessenger) Close() error {
+	if closer, ok := M.messenger.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (M *CachingMessenger) key(meth string, params []interface{}) string {
+	encodedParams, _ := json.Marshal(params)
+	return M.node + " " + meth + " " + string(encodedParams)
+}
+
+// lookup returns a copy of the cached reply for key, looking on disk after memory
+func (M *CachingMessenger) lookup(key string) (Reply, bool) {
+	M.lock.Lock()
+	element, ok := M.entries[key]
+	var entry *cacheEntry
+	if ok {
+		entry = element.Value.(*cacheEntry)
+		if entry.expired() {
+			M.recent.Remove(element)
+			delete(M.entries, key)
+			entry = nil
+		} else {
+			M.recent.MoveToFront(element)
+		}
+	}
+	M.lock.Unlock()
+	if entry == nil {
+		if entry = M.load(key); entry == nil {
+			return nil, false
+		}
+		M.remember(entry)
+	}
+	reply := Reply{}
+	decoder := json.NewDecoder(bytes.NewReader(entry.Reply))
+	decoder.UseNumber()
+	if decoder.Decode(&reply) != nil {
+		return nil, false
+	}
+	return reply, true
+}
+
+// keep caches reply when its result can no longer change or meth has a TTL
+func (M *CachingMessenger) keep(key, meth string, params []interface{}, reply Reply) {
+	entry := &cacheEntry{Key: key}
+	if ttl, ok := M.ttls[meth]; ok {
+		entry.Expires = time.Now().Add(ttl)
+	} else if !immutable(meth, params, reply["result"]) {
+		return
+	}
+	entry.Reply, _ = json.Marshal(reply)
+	M.remember(entry)
+	if entry.Expires.IsZero() && byNumber(meth) {
+		return
+	}
+	M.store(entry)
+}
+
+func (M *CachingMessenger) remember(entry *cacheEntry) {
+	if M.size <= 0 {
+		return
+	}
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	if element, ok := M.entries[entry.Key]; ok {
+		element.Value = entry
+		M.recent.MoveToFront(element)
+		return
+	}
+	M.entries[entry.Key] = M.recent.PushFront(entry)
+	for M.recent.Len() > M.size {
+		oldest := M.recent.Back()
+		M.recent.Remove(oldest)
+		delete(M.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+func (M *CachingMessenger) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(M.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (M *CachingMessenger) load(key string) *cacheEntry {
+	if M.dir == "" {
+		return nil
+	}
+	raw, err := ioutil.ReadFile(M.path(key))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if json.Unmarshal(raw, entry) != nil || entry.Key != key {
+		return nil
+	}
+	if entry.expired() {
+		os.Remove(M.path(key))
+		return nil
+	}
+	return entry
+}
+
+// store writes entry to disk, a failing disk only costs a round trip later on
+func (M *CachingMessenger) store(entry *cacheEntry) {
+	if M.dir == "" {
+		return
+	}
+	if os.MkdirAll(M.dir, 0700) != nil {
+		return
+	}
+	raw, _ := json.Marshal(entry)
+	temp, err := ioutil.TempFile(M.dir, "entry-")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(raw)
+	temp.Close()
+	if err != nil || os.Rename(temp.Name(), M.path(entry.Key)) != nil {
+		os.Remove(temp.Name())
+		return
+	}
+	M.evict()
+}
+
+// evict removes the oldest entries on disk beyond M.dirSize
+func (M *CachingMessenger) evict() {
+	files, err := ioutil.ReadDir(M.dir)
+	if err != nil {
+		return
+	}
+	entries := files[:0]
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".json") {
+			entries = append(entries, file)
+		}
+	}
+	if len(entries) <= M.dirSize {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime().Before(entries[j].ModTime()) })
+	for _, file := range entries[:len(entries)-M.dirSize] {
+		os.Remove(filepath.Join(M.dir, file.Name()))
+	}
+}
+
+func (entry *cacheEntry) expired() bool {
+	return !entry.Expires.IsZero() && time.Now().After(entry.Expires)
+}
+
+// immutable reports if result, the answer to meth with params, can no longer
+// change. Harmony blocks are final once they exist, so only data that is not
+// in a block yet, or asked for by a tag like latest, can
+func immutable(meth string, params []interface{}, result interface{}) bool {
+	if result == nil {
+		return false
+	}
+	switch meth {
+	case Method.GetBlockByHash,
+		Method.GetBlockTransactionCountByHash,
+		Method.GetTransactionByBlockHashAndIndex:
+		return true
+	case Method.GetBlockByNumber,
+		Method.GetBlockTransactionCountByNumber,
+		Method.GetTransactionByBlockNumberAndIndex:
+		// A block number only names a final block until the node is reset
+		if len(params) == 0 {
+			return false
+		}
+		number, _ := params[0].(string)
+		return strings.HasPrefix(number, "0x")
+	case Method.GetTransactionByHash, Method.GetTransactionReceipt:
+		mined, _ := result.(map[string]interface{})
+		// Some nodes send a zero hash instead of null while the transaction is pending
+		blockHash, _ := mined["blockHash"].(string)
+		return strings.Trim(blockHash, "0x") != ""
+	}
+	return false
+}
+
+// byNumber reports if meth looks a block up by its number
+func byNumber(meth string) bool {
+	switch meth {
+	case Method.GetBlockByNumber,
+		Method.GetBlockTransactionCountByNumber,
+		Method.GetTransactionByBlockNumberAndIndex:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingNode echoes calls like paramsNode and counts the requests
+func countingNode(t *testing.T) (*httptest.Server, *int32) {
+	hits, echo := new(int32), echoHandler(t)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		echo(w, r)
+	})), hits
+}
+
+func TestCachingMessengerKeepsOnlyImmutableReplies(t *testing.T) {
+	server, hits := countingNode(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "rpc-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hash := []interface{}{"0x01", true}
+	cached := NewCachingHandler(server.URL, NewHTTPHandler(server.URL), 8).WithDiskCache(dir, 8)
+
+	for i := 0; i < 2; i++ {
+		if reply, err := cached.SendRPC(Method.GetBlockByHash, hash); err != nil || reply["result"] != "0x01" {
+			t.Fatalf("unexpected reply %v %v", reply, err)
+		}
+		cached.SendRPC(Method.BlockNumber, []interface{}{})
+	}
+	if n := atomic.LoadInt32(hits); n != 3 {
+		t.Errorf("expected the block once and the height twice, got %d requests", n)
+	}
+
+	// A fresh process finds the block on disk
+	fresh := NewCachingHandler(server.URL, NewHTTPHandler(server.URL), 8).WithDiskCache(dir, 8)
+	replies, err := fresh.SendBatch([]Call{{Method.GetBlockByHash, hash}, {Method.GasPrice, []interface{}{}}})
+	if err != nil || replies[0].Reply["result"] != "0x01" || replies[1].Reply["result"] != Method.GasPrice {
+		t.Fatalf("unexpected batch %+v %v", replies, err)
+	}
+	if n := atomic.LoadInt32(hits); n != 4 {
+		t.Errorf("expected only the gas price to be sent, got %d requests", n)
+	}
+}
+
+func TestCachingMessengerBoundsDiskCache(t *testing.T) {
+	server, hits := countingNode(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "rpc-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cached := NewCachingHandler(server.URL, NewHTTPHandler(server.URL), 8).WithDiskCache(dir, 2)
+	for _, hash := range []string{"0x01", "0x02", "0x03"} {
+		cached.SendRPC(Method.GetBlockByHash, []interface{}{hash, true})
+		// Entries are told apart by their modification time
+		time.Sleep(10 * time.Millisecond)
+	}
+	number := []interface{}{"0x10", true}
+	cached.SendRPC(Method.GetBlockByNumber, number)
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("expected 2 entries on disk, found %d", len(files))
+	}
+
+	// The block by number was only in memory, the oldest block by hash was evicted
+	fresh := NewCachingHandler(server.URL, NewHTTPHandler(server.URL), 8).WithDiskCache(dir, 2)
+	atomic.StoreInt32(hits, 0)
+	fresh.SendRPC(Method.GetBlockByHash, []interface{}{"0x03", true})
+	fresh.SendRPC(Method.GetBlockByNumber, number)
+	fresh.SendRPC(Method.GetBlockByHash, []interface{}{"0x01", true})
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("expected the block by number and the evicted block to be sent, got %d requests", n)
+	}
+}
+
+func TestCachingMessengerExpiresTTL(t *testing.T) {
+	server, hits := flakyNode(t, 0)
+	defer server.Close()
+	cached := NewCachingHandler(server.URL, NewHTTPHandler(server.URL), 8).WithTTL(Method.PeerCount, 20*time.Millisecond)
+	cached.SendRPC(Method.PeerCount, []interface{}{})
+	cached.SendRPC(Method.PeerCount, []interface{}{})
+	time.Sleep(30 * time.Millisecond)
+	cached.SendRPC(Method.PeerCount, []interface{}{})
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("expected a request before and after expiry, got %d", n)
+	}
+}
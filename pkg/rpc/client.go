@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotFound is returned when the node answers null, e.g. for a receipt of a pending transaction
+	ErrNotFound = errors.New("not found")
+)
+
+// Client wraps a messenger with typed calls, replies of an unexpected shape
+// come back as errors instead of failed type assertions
+type Client struct {
+	messenger T
+}
+
+// NewClient returns a Client sending its calls through messenger
+func NewClient(messenger T) *Client {
+	return &Client{messenger}
+}
+
+// BlockNumber is the height of the latest block
+func (C *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	var number hexutil.Uint64
+	err := C.call(ctx, &number, Method.BlockNumber)
+	return uint64(number), err
+}
+
+// GasPrice is the node's suggested gas price in atto
+func (C *Client) GasPrice(ctx context.Context) (*big.Int, error) {
+	var price hexutil.Big
+	if err := C.call(ctx, &price, Method.GasPrice); err != nil {
+		return nil, err
+	}
+	return price.ToInt(), nil
+}
+
+// EstimateGas is how much gas the node expects the call described by args to use
+func (C *Client) EstimateGas(ctx context.Context, args CallArgs) (uint64, error) {
+	var gas hexutil.Uint64
+	err := C.call(ctx, &gas, Method.EstimateGas, args)
+	return uint64(gas), err
+}
+
+// GetCode is the latest code deployed at addr, empty unless addr is a contract
+func (C *Client) GetCode(ctx context.Context, addr string) ([]byte, error) {
+	var code hexutil.Bytes
+	err := C.call(ctx, &code, Method.GetCode, addr, "latest")
+	return code, err
+}
+
+// GetBalance is the latest balance of addr in atto
+func (C *Client) GetBalance(ctx context.Context, addr string) (*big.Int, error) {
+	var balance hexutil.Big
+	if err := C.call(ctx, &balance, Method.GetBalance, addr, "latest"); err != nil {
+		return nil, err
+	}
+	return balance.ToInt(), nil
+}
+
+// GetTransactionCount is the nonce the next transaction from addr should use
+func (C *Client) GetTransactionCount(ctx context.Context, addr string) (uint64, error) {
+	var count hexutil.Uint64
+	err := C.call(ctx, &count, Method.GetTransactionCount, addr, "latest")
+	return uint64(count), err
+}
+
+// GetBlockByNumber fetches a block, nil number means the latest one
+func (C *Client) GetBlockByNumber(ctx context.Context, number *big.Int, fullTx bool) (*Block, error) {
+	block := &Block{}
+	if err := C.call(ctx, block, Method.GetBlockByNumber, blockArg(number), fullTx); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetBlockByHash fetches the block with the given hash
+func (C *Client) GetBlockByHash(ctx context.Context, hash string, fullTx bool) (*Block, error) {
+	block := &Block{}
+	if err := C.call(ctx, block, Method.GetBlockByHash, hash, fullTx); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetTransactionByHash fetches a transaction, pending or mined
+func (C *Client) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	tx := &Transaction{}
+	if err := C.call(ctx, tx, Method.GetTransactionByHash, hash); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetTransactionReceipt fetches the receipt of a mined transaction, ErrNotFound
+// while the transaction is still pending
+func (C *Client) GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	receipt := &Receipt{}
+	if err := C.call(ctx, receipt, Method.GetTransactionReceipt, hash); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// GetValidatorInformation fetches the validator registered under addr
+func (C *Client) GetValidatorInformation(ctx context.Context, addr string) (*ValidatorInformation, error) {
+	validator := &ValidatorInformation{}
+	if err := C.call(ctx, validator, Method.GetValidatorInformation, addr); err != nil {
+		return nil, err
+	}
+	return validator, nil
+}
+
+// GetNodeMetadata describes the node itself, its version, network and chain id
+func (C *Client) GetNodeMetadata(ctx context.Context) (*NodeMetadata, error) {
+	metadata := &NodeMetadata{}
+	if err := C.call(ctx, metadata, Method.GetNodeMetadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// ProtocolVersion is the version of the Harmony protocol the node speaks
+func (C *Client) ProtocolVersion(ctx context.Context) (uint64, error) {
+	var version hexutil.Uint64
+	err := C.call(ctx, &version, Method.ProtocolVersion)
+	return uint64(version), err
+}
+
+// SendRawTransaction broadcasts a signed, hex encoded transaction and returns its hash
+func (C *Client) SendRawTransaction(ctx context.Context, signed string) (string, error) {
+	hash := ""
+	err := C.call(ctx, &hash, Method.SendRawTransaction, signed)
+	return hash, err
+}
+
+// call decodes the result of method into result
+func (C *Client) call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	reply, err := SendRPCContext(ctx, C.messenger, method, params)
+	if err != nil {
+		return err
+	}
+	raw, ok := reply["result"]
+	if !ok {
+		return fmt.Errorf("%s reply has no result", method)
+	}
+	if raw == nil {
+		return ErrNotFound
+	}
+	asJSON, _ := json.Marshal(raw)
+	if err := json.Unmarshal(asJSON, result); err != nil {
+		return errors.Wrapf(err, "unexpected %s result", method)
+	}
+	return nil
+}
+
+func blockArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cannedNode answers each method with the raw JSON result in results
+func cannedNode(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		call := map[string]interface{}{}
+		if err := json.Unmarshal(body, &call); err != nil {
+			t.Error(err)
+			return
+		}
+		result, ok := results[call["method"].(string)]
+		if !ok {
+			t.Errorf("unexpected call to %v", call["method"])
+			return
+		}
+		id, _ := json.Marshal(call["id"])
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(id) + `,"result":` + result + `}`))
+	}))
+}
+
+func TestClientDecodesResults(t *testing.T) {
+	server := cannedNode(t, map[string]string{
+		Method.GetBalance:            `"0xde0b6b3a7640000"`,
+		Method.GetBlockByNumber:      `{"number":"0x10","hash":"0xabc","transactions":["0x01","0x02"]}`,
+		Method.GetTransactionReceipt: `null`,
+		Method.GetValidatorInformation: `{"address":"one1x","min-self-delegation":10000000000000000000000,` +
+			`"rate":"0.100000000000000000","bls-public-keys":["aa"]}`,
+		Method.BlockNumber: `{"unexpected":"shape"}`,
+	})
+	defer server.Close()
+	client := NewClient(NewHTTPHandler(server.URL))
+	ctx := context.Background()
+
+	balance, err := client.GetBalance(ctx, "one1x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.String() != "1000000000000000000" {
+		t.Errorf("unexpected balance %s", balance)
+	}
+
+	block, err := client.GetBlockByNumber(ctx, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Number != 16 || len(block.Transactions) != 2 || block.Transactions[1].Hash != "0x02" {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	if _, err := client.GetTransactionReceipt(ctx, "0x01"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	validator, err := client.GetValidatorInformation(ctx, "one1x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validator.MinSelfDelegation.String() != "10000000000000000000000" {
+		t.Errorf("min self delegation lost precision: %s", validator.MinSelfDelegation)
+	}
+
+	if _, err := client.BlockNumber(ctx); err == nil {
+		t.Error("expected an error for a reply of the wrong shape")
+	}
+}
+
+func TestClientDecodesNodeBlock(t *testing.T) {
+	// Shaped as a Harmony node answers hmy_getBlockByNumber, with a numeric nonce
+	recorded, err := ioutil.ReadFile("testdata/block.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := struct{ Result json.RawMessage }{}
+	if err := json.Unmarshal(recorded, &response); err != nil {
+		t.Fatal(err)
+	}
+	server := cannedNode(t, map[string]string{Method.GetBlockByNumber: string(response.Result)})
+	defer server.Close()
+	block, err := NewClient(NewHTTPHandler(server.URL)).GetBlockByNumber(context.Background(), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Number != 0x1388c || block.Nonce != 0 || block.GasUsed != 21000 || len(block.Transactions) != 1 {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	// Nodes following the Ethereum API send it as hex instead
+	hexNonce := Block{}
+	if err := json.Unmarshal([]byte(`{"nonce":"0x2a"}`), &hexNonce); err != nil || hexNonce.Nonce != 42 {
+		t.Errorf("hex nonce decoded as %d, %v", hexNonce.Nonce, err)
+	}
+}
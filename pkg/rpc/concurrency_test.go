@@ -0,0 +1,193 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	concurrentCallers = 16
+	callsPerCaller    = 25
+)
+
+type echoCall struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+}
+
+// echoReply answers with the first parameter of call, or its method when it has none
+func echoReply(call echoCall) map[string]interface{} {
+	var result interface{} = call.Method
+	if len(call.Params) > 0 {
+		result = call.Params[0]
+	}
+	return map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": result}
+}
+
+// paramsNode echoes single calls and batches over HTTP, replies to a batch come back reversed
+func paramsNode(t *testing.T) *httptest.Server {
+	return httptest.NewServer(echoHandler(t))
+}
+
+func echoHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		batch := []echoCall{}
+		if json.Unmarshal(body, &batch) == nil {
+			replies := make([]map[string]interface{}, len(batch))
+			for i, call := range batch {
+				replies[len(batch)-1-i] = echoReply(call)
+			}
+			json.NewEncoder(w).Encode(replies)
+			return
+		}
+		call := echoCall{}
+		if err := json.Unmarshal(body, &call); err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewEncoder(w).Encode(echoReply(call))
+	}
+}
+
+// wsParamsNode echoes every call over WebSocket, each reply is sent from its own goroutine so they arrive out of order
+func wsParamsNode(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var writeMu sync.Mutex
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			go func(message []byte) {
+				call := echoCall{}
+				json.Unmarshal(message, &call)
+				reply, _ := json.Marshal(echoReply(call))
+				writeMu.Lock()
+				conn.WriteMessage(websocket.TextMessage, reply)
+				writeMu.Unlock()
+			}(message)
+		}
+	}))
+}
+
+// hammer runs call from concurrentCallers goroutines, callsPerCaller times each
+func hammer(t *testing.T, call func(tag string) error) {
+	var wg sync.WaitGroup
+	for caller := 0; caller < concurrentCallers; caller++ {
+		wg.Add(1)
+		go func(caller int) {
+			defer wg.Done()
+			for i := 0; i < callsPerCaller; i++ {
+				if err := call(fmt.Sprintf("%d-%d", caller, i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(caller)
+	}
+	wg.Wait()
+}
+
+func expectEcho(messenger T) func(string) error {
+	return func(tag string) error {
+		reply, err := messenger.SendRPC(Method.GetBalance, []interface{}{tag})
+		if err != nil {
+			return err
+		}
+		if reply["result"] != tag {
+			return fmt.Errorf("call %s got the reply of %v", tag, reply["result"])
+		}
+		return nil
+	}
+}
+
+func TestConcurrentHTTPSendRPC(t *testing.T) {
+	server := paramsNode(t)
+	defer server.Close()
+	hammer(t, expectEcho(NewHTTPHandler(server.URL)))
+}
+
+func TestConcurrentHTTPSendBatch(t *testing.T) {
+	server := paramsNode(t)
+	defer server.Close()
+	messenger := NewHTTPHandler(server.URL)
+	hammer(t, func(tag string) error {
+		calls := []Call{
+			{Method.GetBalance, []interface{}{tag + "a"}},
+			{Method.GetBalance, []interface{}{tag + "b"}},
+		}
+		replies, err := messenger.SendBatch(calls)
+		if err != nil {
+			return err
+		}
+		for i, reply := range replies {
+			if reply.Error != nil {
+				return reply.Error
+			}
+			if reply.Reply["result"] != calls[i].Params[0] {
+				return fmt.Errorf("call %v got the reply of %v", calls[i].Params[0], reply.Reply["result"])
+			}
+		}
+		return nil
+	})
+}
+
+func TestConcurrentWSSendRPC(t *testing.T) {
+	server := wsParamsNode(t)
+	defer server.Close()
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer messenger.Close()
+	hammer(t, expectEcho(messenger))
+}
+
+func TestConcurrentPoolSendRPC(t *testing.T) {
+	first, second := paramsNode(t), paramsNode(t)
+	defer first.Close()
+	defer second.Close()
+	pool := NewPoolHandler([]string{first.URL, second.URL}).WithRoundRobin(true)
+	defer pool.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := pool.HealthCheck(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	hammer(t, expectEcho(pool))
+	<-done
+}
+
+func TestReplyWithAnotherIDIsRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":0,"result":"0x1"}`)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := NewHTTPHandler(server.URL).SendRPCContext(ctx, Method.BlockNumber, []interface{}{})
+	if errors.Cause(err) != ErrIDMismatch {
+		t.Errorf("expected ErrIDMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// RPCError is an error object sent back by the node, match it by code with
+// errors.Is against the sentinels below or get at the details with errors.As
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	description := codeToMessage(float64(e.Code))
+	if e.Message == "" {
+		return description
+	}
+	return description + ": " + e.Message
+}
+
+// Is matches any RPCError carrying the same code
+func (e *RPCError) Is(target error) bool {
+	t, ok := target.(*RPCError)
+	return ok && t.Code == e.Code
+}
+
+// Sentinels for the known error codes, e.g. errors.Is(err, rpc.ErrInWarmup)
+var (
+	ErrInvalidRequest       = &RPCError{Code: int(errorCodeEnumeration.rpcInvalidRequest)}
+	ErrMethodNotFound       = &RPCError{Code: int(errorCodeEnumeration.rpcMethodNotFound)}
+	ErrInvalidParams        = &RPCError{Code: int(errorCodeEnumeration.rpcInvalidParams)}
+	ErrInternalError        = &RPCError{Code: int(errorCodeEnumeration.rpcInternalError)}
+	ErrParseError           = &RPCError{Code: int(errorCodeEnumeration.rpcParseError)}
+	ErrMiscError            = &RPCError{Code: int(errorCodeEnumeration.rpcMiscError)}
+	ErrTypeError            = &RPCError{Code: int(errorCodeEnumeration.rpcTypeError)}
+	ErrInvalidAddressOrKey  = &RPCError{Code: int(errorCodeEnumeration.rpcInvalidAddressOrKey)}
+	ErrInvalidParameter     = &RPCError{Code: int(errorCodeEnumeration.rpcInvalidParameter)}
+	ErrDatabaseError        = &RPCError{Code: int(errorCodeEnumeration.rpcDatabaseError)}
+	ErrDeserializationError = &RPCError{Code: int(errorCodeEnumeration.rpcDeserializationError)}
+	ErrVerifyError          = &RPCError{Code: int(errorCodeEnumeration.rpcVerifyError)}
+	ErrVerifyRejected       = &RPCError{Code: int(errorCodeEnumeration.rpcVerifyRejected)}
+	ErrInWarmup             = &RPCError{Code: int(errorCodeEnumeration.rpcInWarmup)}
+	ErrMethodDeprecated     = &RPCError{Code: int(errorCodeEnumeration.rpcMethodDeprecated)}
+	ErrIncorrectChainID     = &RPCError{Code: int(errorCodeEnumeration.rpcIncorrectChainID)}
+)
+
+// IsMethodNotFound tells if the node does not know the method called, however err was wrapped
+func IsMethodNotFound(err error) bool {
+	rpcErr, ok := errors.Cause(err).(*RPCError)
+	return ok && rpcErr.Is(ErrMethodNotFound)
+}
+
+// liftRPCError reads the error member of a reply, whatever shape the node gave it
+func liftRPCError(oops interface{}) *RPCError {
+	fields, ok := oops.(map[string]interface{})
+	if !ok {
+		return &RPCError{Message: fmt.Sprintf("%v", oops)}
+	}
+	rpcErr := &RPCError{Data: fields["data"]}
+	switch code := fields["code"].(type) {
+	case json.Number:
+		n, _ := code.Int64()
+		rpcErr.Code = int(n)
+	case float64:
+		rpcErr.Code = int(code)
+	}
+	rpcErr.Message, _ = fields["message"].(string)
+	return rpcErr
+}
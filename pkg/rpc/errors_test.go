@@ -0,0 +1,29 @@
+package rpc
+
+import "testing"
+
+func TestLiftReplyReturnsRPCError(t *testing.T) {
+	_, err := liftReply([]byte(`{"jsonrpc":"2.0","id":"1","error":{"code":-28,"message":"busy","data":"0x01"}}`))
+	rpcErr, ok := err.(*RPCError)
+	if !ok {
+		t.Fatalf("expected *RPCError, got %T", err)
+	}
+	if rpcErr.Code != -28 || rpcErr.Message != "busy" || rpcErr.Data != "0x01" {
+		t.Errorf("unexpected error %+v", rpcErr)
+	}
+	if !rpcErr.Is(ErrInWarmup) || rpcErr.Is(ErrInvalidParams) {
+		t.Error("error should only match its own code")
+	}
+}
+
+func TestLiftReplyToleratesUnknownErrors(t *testing.T) {
+	for _, raw := range []string{
+		`{"error":{"code":-12345,"message":"new in this node version"}}`,
+		`{"error":{"message":"no code"}}`,
+		`{"error":"just a string"}`,
+	} {
+		if _, err := liftReply([]byte(raw)); err == nil || err.Error() == "" {
+			t.Errorf("%s should come back as an error", raw)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// FilterManager creates filters on a node and polls them, a filter the node
+// dropped, e.g. because it was not polled in time, is created again. Filters
+// live on one node, use it over a single endpoint or a pool without round robin
+type FilterManager struct {
+	client *Client
+}
+
+// NewFilterManager returns a FilterManager sending its calls through messenger
+func NewFilterManager(messenger T) *FilterManager {
+	return &FilterManager{NewClient(messenger)}
+}
+
+// Filter is a filter installed on the node, Poll* return what happened since the last poll
+type Filter struct {
+	manager *FilterManager
+	method  string
+	query   *FilterQuery
+	lock    sync.Mutex
+	id      string
+	// next is the first block whose logs have not been delivered yet, zero until a log was
+	next uint64
+}
+
+// GetLogs returns the logs matching q, by default those of the latest block
+func (M *FilterManager) GetLogs(ctx context.Context, q FilterQuery) ([]Log, error) {
+	logs := []Log{}
+	err := M.client.call(ctx, &logs, Method.GetPastLogs, q)
+	return logs, err
+}
+
+// NewLogFilter installs a filter for the logs matching q from now on
+func (M *FilterManager) NewLogFilter(ctx context.Context, q FilterQuery) (*Filter, error) {
+	return M.install(ctx, &Filter{manager: M, method: Method.NewFilter, query: &q})
+}
+
+// NewBlockFilter installs a filter for the hashes of new blocks
+func (M *FilterManager) NewBlockFilter(ctx context.Context) (*Filter, error) {
+	return M.install(ctx, &Filter{manager: M, method: Method.NewBlockFilter})
+}
+
+// NewPendingTransactionFilter installs a filter for the hashes of transactions entering the pool
+func (M *FilterManager) NewPendingTransactionFilter(ctx context.Context) (*Filter, error) {
+	return M.install(ctx, &Filter{manager: M, method: Method.NewPendingTransactionFilter})
+}
+
+func (M *FilterManager) install(ctx context.Context, F *Filter) (*Filter, error) {
+	if err := F.create(ctx); err != nil {
+		return nil, err
+	}
+	return F, nil
+}
+
+// FollowLogs delivers the logs matching q as the node produces them, polling
+// every interval until ctx is done. When q.FromBlock is set the logs since then
+// are delivered first. Reaching the end of ctx is not an error
+func (M *FilterManager) FollowLogs(
+	ctx context.Context, q FilterQuery, interval time.Duration, deliver func(Log),
+) error {
+	past := q
+	past.ToBlock = "latest"
+	q.FromBlock, q.ToBlock = "", ""
+	filter, err := M.NewLogFilter(ctx, q)
+	if err != nil {
+		return err
+	}
+	// The filter is up before the past logs are read so no block falls in between
+	if past.FromBlock != "" {
+		logs, err := M.GetLogs(ctx, past)
+		if err != nil {
+			return err
+		}
+		for _, log := range filter.unseen(logs) {
+			deliver(log)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		logs, err := filter.PollLogs(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			deliver(log)
+		}
+	}
+}
+
+// ID is the current id of the filter on the node, it changes when the filter is created again
+func (F *Filter) ID() string {
+	F.lock.Lock()
+	defer F.lock.Unlock()
+	return F.id
+}
+
+// PollLogs returns the logs of a log filter since the last poll, when the
+// filter had to be created again the logs missed meanwhile are looked up
+func (F *Filter) PollLogs(ctx context.Context) ([]Log, error) {
+	if F.query == nil {
+		return nil, errors.New("not a log filter")
+	}
+	logs := []Log{}
+	recreated, err := F.changes(ctx, &logs)
+	if err != nil {
+		return nil, err
+	}
+	F.lock.Lock()
+	next := F.next
+	F.lock.Unlock()
+	if recreated && next > 0 {
+		missed := *F.query
+		missed.FromBlock, missed.ToBlock = hexutil.EncodeUint64(next), "latest"
+		gap, err := F.manager.GetLogs(ctx, missed)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(gap, logs...)
+	}
+	return F.unseen(logs), nil
+}
+
+// PollHashes returns the block or transaction hashes of a block or pending
+// transaction filter since the last poll, those seen while the filter had to
+// be created again are lost
+func (F *Filter) PollHashes(ctx context.Context) ([]string, error) {
+	if F.query != nil {
+		return nil, errors.New("not a block or pending transaction filter")
+	}
+	hashes := []string{}
+	if _, err := F.changes(ctx, &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// unseen drops the logs of blocks already delivered and moves next past the rest
+func (F *Filter) unseen(logs []Log) []Log {
+	F.lock.Lock()
+	defer F.lock.Unlock()
+	fresh := []Log{}
+	for _, log := range logs {
+		if uint64(log.BlockNumber) < F.next {
+			continue
+		}
+		fresh = append(fresh, log)
+	}
+	for _, log := range fresh {
+		if uint64(log.BlockNumber)+1 > F.next {
+			F.next = uint64(log.BlockNumber) + 1
+		}
+	}
+	return fresh
+}
+
+// changes decodes hmy_getFilterChanges into result, creating the filter again
+// if the node no longer knows it
+func (F *Filter) changes(ctx context.Context, result interface{}) (bool, error) {
+	id := F.ID()
+	err := F.manager.client.call(ctx, result, Method.GetFilterChanges, id)
+	if !filterGone(err) {
+		return false, err
+	}
+	if err := F.create(ctx); err != nil {
+		return false, errors.Wrap(err, "could not create expired filter again")
+	}
+	return true, F.manager.client.call(ctx, result, Method.GetFilterChanges, F.ID())
+}
+
+func (F *Filter) create(ctx context.Context) error {
+	params := []interface{}{}
+	if F.query != nil {
+		params = append(params, *F.query)
+	}
+	id := ""
+	if err := F.manager.client.call(ctx, &id, F.method, params...); err != nil {
+		return err
+	}
+	F.lock.Lock()
+	F.id = id
+	F.lock.Unlock()
+	return nil
+}
+
+// filterGone reports if err is the node telling it does not know a filter
+func filterGone(err error) bool {
+	rpcErr, ok := errors.Cause(err).(*RPCError)
+	return ok && strings.Contains(strings.ToLower(rpcErr.Message), "filter not found")
+}
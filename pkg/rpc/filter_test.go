@@ -0,0 +1,49 @@
+package rpc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestFilterManagerSurvivesExpiredFilters(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	const contract, other = "0x00000000000000000000000000000000000000c1", "0x00000000000000000000000000000000000000c2"
+	transfer := "0x" + strings.Repeat("ab", 32)
+	node.EmitLog(rpc.Log{Address: contract, Topics: []string{transfer}, Data: "0x01"})
+	manager := rpc.NewFilterManager(rpc.NewHTTPHandler(node.URL))
+	ctx := context.Background()
+
+	q := rpc.FilterQuery{Address: []string{contract}, Topics: [][]string{{transfer}}}
+	filter, err := manager.NewLogFilter(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.EmitLog(rpc.Log{Address: contract, Topics: []string{transfer}, Data: "0x02"})
+	node.EmitLog(rpc.Log{Address: other, Topics: []string{transfer}, Data: "0x03"})
+	logs, err := filter.PollLogs(ctx)
+	if err != nil || len(logs) != 1 || logs[0].Data != "0x02" {
+		t.Fatalf("expected the second log only, got %+v %v", logs, err)
+	}
+
+	id := filter.ID()
+	node.ExpireFilters()
+	node.EmitLog(rpc.Log{Address: contract, Topics: []string{transfer}, Data: "0x04"})
+	if logs, err = filter.PollLogs(ctx); err != nil || len(logs) != 1 || logs[0].Data != "0x04" {
+		t.Fatalf("expected the log missed while expired, got %+v %v", logs, err)
+	}
+	if filter.ID() == id {
+		t.Error("filter was not created again")
+	}
+
+	q.FromBlock = "earliest"
+	all, err := manager.GetLogs(ctx, q)
+	if err != nil || len(all) != 3 {
+		t.Errorf("expected 3 past logs, got %+v %v", all, err)
+	}
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// DefaultGasPrice is 1 nano, suggested when neither the node nor recent blocks tell otherwise
+var DefaultGasPrice = big.NewInt(1e9)
+
+// GasPriceOracle suggests a gas price, the one of hmy_gasPrice when the node
+// has one and otherwise a percentile of what recent transactions paid
+type GasPriceOracle struct {
+	client *Client
+	// Blocks is how many of the latest blocks are sampled
+	Blocks int
+	// Percentile of the sampled prices suggested, 50 is the median
+	Percentile int
+}
+
+// NewGasPriceOracle samples the 20 latest blocks and suggests their 60th percentile
+func NewGasPriceOracle(messenger T) *GasPriceOracle {
+	return &GasPriceOracle{client: NewClient(messenger), Blocks: 20, Percentile: 60}
+}
+
+// SuggestGasPrice is a gas price in atto likely to get a transaction in soon
+func (O *GasPriceOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	price, err := O.client.GasPrice(ctx)
+	switch {
+	case err == nil && price.Sign() > 0:
+		return price, nil
+	case err != nil && !IsMethodNotFound(err):
+		return nil, err
+	}
+	prices, err := O.recentPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) == 0 {
+		return new(big.Int).Set(DefaultGasPrice), nil
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	at := (len(prices) - 1) * O.Percentile / 100
+	if at >= len(prices) {
+		at = len(prices) - 1
+	}
+	return prices[at], nil
+}
+
+// recentPrices gathers the gas price of every transaction in the latest blocks, asked in one batch
+func (O *GasPriceOracle) recentPrices(ctx context.Context) ([]*big.Int, error) {
+	head, err := O.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calls := []Call{}
+	for i := uint64(0); i < uint64(O.Blocks) && i <= head; i++ {
+		number := new(big.Int).SetUint64(head - i)
+		calls = append(calls, Call{Method: Method.GetBlockByNumber, Params: []interface{}{blockArg(number), true}})
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	replies, err := SendBatchContext(ctx, O.client.messenger, calls)
+	if err != nil {
+		return nil, err
+	}
+	prices := []*big.Int{}
+	for _, reply := range replies {
+		if reply.Error != nil || reply.Reply["result"] == nil {
+			continue
+		}
+		asJSON, _ := json.Marshal(reply.Reply["result"])
+		block := Block{}
+		if json.Unmarshal(asJSON, &block) != nil {
+			continue
+		}
+		for _, tx := range block.Transactions {
+			if tx.GasPrice != nil {
+				prices = append(prices, tx.GasPrice.ToInt())
+			}
+		}
+	}
+	return prices, nil
+}
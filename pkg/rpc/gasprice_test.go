@@ -0,0 +1,38 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestGasPriceOracleSamplesRecentBlocks(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	node.Handle(rpc.Method.GasPrice, func([]json.RawMessage) (interface{}, error) {
+		return nil, &rpc.RPCError{Code: rpc.ErrMethodNotFound.Code, Message: "no gas price"}
+	})
+	// Block n holds transactions paying n and n+10 atto
+	for n := int64(1); n <= 3; n++ {
+		node.MineTransactions(
+			rpc.Transaction{Hash: "0x1", GasPrice: (*hexutil.Big)(big.NewInt(n))},
+			rpc.Transaction{Hash: "0x2", GasPrice: (*hexutil.Big)(big.NewInt(n + 10))},
+		)
+	}
+	oracle := rpc.NewGasPriceOracle(rpc.NewHTTPHandler(node.URL))
+	oracle.Blocks, oracle.Percentile = 2, 50
+	// Blocks 3 and 2 paid 2, 3, 12 and 13
+	price, err := oracle.SuggestGasPrice(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Int64() != 3 {
+		t.Errorf("expected the median of the 2 latest blocks, got %s", price)
+	}
+}
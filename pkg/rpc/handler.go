@@ -1,20 +1,128 @@
 package rpc
 
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Reply is a JSON-RPC response as decoded by a messenger. Numbers in it are
+// json.Number rather than float64 so amounts past 2^53 stay exact, this breaks
+// callers that asserted float64 before the typed Client was added
 type Reply map[string]interface{}
 
 type T interface {
 	SendRPC(string, []interface{}) (Reply, error)
 }
 
+// ContextT is a messenger whose calls give up once their context is done
+type ContextT interface {
+	T
+	SendRPCContext(context.Context, string, []interface{}) (Reply, error)
+}
+
+// SendRPCContext bounds the call by ctx, messengers that are not a ContextT
+// keep running in the background after ctx is done but the caller is released
+func SendRPCContext(ctx context.Context, messenger T, meth string, params []interface{}) (Reply, error) {
+	if withContext, ok := messenger.(ContextT); ok {
+		return withContext.SendRPCContext(ctx, meth, params)
+	}
+	type outcome struct {
+		reply Reply
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		reply, err := messenger.SendRPC(meth, params)
+		done <- outcome{reply, err}
+	}()
+	select {
+	case o := <-done:
+		return o.reply, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 type HTTPMessenger struct {
-	node string
+	node         string
+	client       *fasthttp.Client
+	retry        RetryPolicy
+	interceptors []Interceptor
+	// invalid fails every call when the node url was rejected by NewHTTPHandler
+	invalid error
 }
 
 func (M *HTTPMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
-	return Request(meth, M.node, params)
+	return M.SendRPCContext(context.Background(), meth, params)
+}
+
+// SendRPCContext is SendRPC bounded by ctx
+func (M *HTTPMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	if M.invalid != nil {
+		return nil, M.invalid
+	}
+	var reply Reply
+	err := M.retry.do(ctx, []string{meth}, func() (err error) {
+		rawReply, err := requestVia(ctx, M.invoker(), meth, M.node, params)
+		if err != nil {
+			return err
+		}
+		reply, err = liftReply(rawReply)
+		return err
+	})
+	return reply, err
+}
+
+// WithRetryPolicy makes the messenger retry transient failures, set it before first use
+func (M *HTTPMessenger) WithRetryPolicy(policy RetryPolicy) *HTTPMessenger {
+	M.retry = policy
+	return M
+}
+
+// WithInterceptors runs every call through interceptors, the first one
+// outermost, on each attempt. Set them before first use
+func (M *HTTPMessenger) WithInterceptors(interceptors ...Interceptor) *HTTPMessenger {
+	M.interceptors = append(M.interceptors, interceptors...)
+	return M
+}
+
+// WithRateLimiter makes every attempt wait for limiter, which may be shared
+// with other messengers, nil leaves calls unlimited. Set it before first use
+func (M *HTTPMessenger) WithRateLimiter(limiter *RateLimiter) *HTTPMessenger {
+	if limiter != nil {
+		M.interceptors = append(M.interceptors, RateLimitInterceptor(limiter))
+	}
+	return M
 }
 
+// invoker is the interceptors of the messenger in front of its client
+func (M *HTTPMessenger) invoker() Invoker {
+	return chain(M.interceptors, httpSender(M.client))
+}
+
+// NewHTTPHandler returns a messenger for an http:// or https:// node with the
+// default transport, when node is not such a url every call fails with ErrInvalidNodeURL
 func NewHTTPHandler(node string) *HTTPMessenger {
-	// TODO Sanity check the URL for HTTP
-	return &HTTPMessenger{node}
+	if err := checkHTTPURL(node); err != nil {
+		return &HTTPMessenger{node: node, client: defaultHTTPClient, invalid: err}
+	}
+	return &HTTPMessenger{node: node, client: defaultHTTPClient}
+}
+
+// NewHTTPHandlerWithOptions is NewHTTPHandler over a transport of its own tuned by options,
+// a bad url, certificate or proxy is reported right away
+func NewHTTPHandlerWithOptions(node string, options HTTPOptions) (*HTTPMessenger, error) {
+	if err := checkHTTPURL(node); err != nil {
+		return nil, err
+	}
+	client, err := options.client()
+	if err != nil {
+		return nil, err
+	}
+	M := &HTTPMessenger{node: node, client: client}
+	if len(options.Header) > 0 {
+		M.interceptors = append(M.interceptors, HeaderInterceptor(options.Header))
+	}
+	return M, nil
 }
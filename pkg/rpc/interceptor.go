@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+)
+
+// Invocation is a call on its way to the node, Calls is set instead of Method
+// and Params for a batch. Interceptors may change it in place before passing
+// it on, Header is sent along with HTTP requests and ignored over WebSocket
+type Invocation struct {
+	Node   string
+	Method string
+	Params interface{}
+	Calls  []Call
+	Header http.Header
+	// ids are assigned to the calls of a batch once it is encoded
+	ids []string
+}
+
+// Invoker carries a request further down the chain, the last one sends it and
+// returns the raw response
+type Invoker func(ctx context.Context, inv *Invocation) ([]byte, error)
+
+// Interceptor wraps every call of a messenger, it has to call next with inv
+// to carry on and may look at or replace the raw response
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error)
+
+// DebugInterceptor prints every request and response, it runs last on its own
+// when common.DebugRPC is set
+var DebugInterceptor = LoggingInterceptor(os.Stdout)
+
+// chain puts interceptors in front of send, the first one runs first
+func chain(interceptors []Interceptor, send Invoker) Invoker {
+	if common.DebugRPC {
+		send = wrap(DebugInterceptor, send)
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		send = wrap(interceptors[i], send)
+	}
+	return send
+}
+
+func wrap(interceptor Interceptor, next Invoker) Invoker {
+	return func(ctx context.Context, inv *Invocation) ([]byte, error) {
+		return interceptor(ctx, inv, next)
+	}
+}
+
+// LoggingInterceptor writes every request and response to w as indented JSON
+func LoggingInterceptor(w io.Writer) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error) {
+		var sent interface{} = map[string]interface{}{"method": inv.Method, "params": inv.Params}
+		if inv.Calls != nil {
+			sent = inv.Calls
+		}
+		asJSON, _ := json.Marshal(sent)
+		fmt.Fprintf(w, "URL: %s, Request Body: %s\n\n", inv.Node, common.JSONPrettyFormat(string(asJSON)))
+		rawReply, err := next(ctx, inv)
+		if err != nil {
+			fmt.Fprintf(w, "URL: %s, Failed: %s\n\n", inv.Node, err)
+			return nil, err
+		}
+		fmt.Fprintf(w, "URL: %s, Response Body: %s\n\n", inv.Node, common.JSONPrettyFormat(string(rawReply)))
+		return rawReply, nil
+	}
+}
+
+// HeaderInterceptor adds header to every HTTP request, e.g. an API key
+func HeaderInterceptor(header http.Header) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error) {
+		for key, values := range header {
+			for _, value := range values {
+				inv.Header.Add(key, value)
+			}
+		}
+		return next(ctx, inv)
+	}
+}
+
+// BearerTokenInterceptor authenticates every HTTP request with token
+func BearerTokenInterceptor(token string) Interceptor {
+	return HeaderInterceptor(http.Header{"Authorization": []string{"Bearer " + token}})
+}
+
+// TimingInterceptor reports how long each request took, method is "batch" for batches
+func TimingInterceptor(observe func(node, method string, took time.Duration, err error)) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error) {
+		method := inv.Method
+		if inv.Calls != nil {
+			method = "batch"
+		}
+		start := time.Now()
+		rawReply, err := next(ctx, inv)
+		observe(inv.Node, method, time.Since(start), err)
+		return rawReply, err
+	}
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterceptorsWrapEveryAttempt(t *testing.T) {
+	server, _ := flakyNode(t, 1)
+	defer server.Close()
+	authorized := make(chan string, 2)
+	guarded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized <- r.Header.Get("Authorization")
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer guarded.Close()
+
+	order := []string{}
+	timings := 0
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryServerErrors: true}
+	messenger := NewHTTPHandler(guarded.URL).WithRetryPolicy(policy).WithInterceptors(
+		func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error) {
+			order = append(order, "outer")
+			inv.Method = Method.GasPrice
+			return next(ctx, inv)
+		},
+		BearerTokenInterceptor("secret"),
+		TimingInterceptor(func(node, method string, took time.Duration, err error) {
+			order = append(order, "timing")
+			if node != guarded.URL || method != Method.GasPrice {
+				t.Errorf("timed %s %s", node, method)
+			}
+			timings++
+		}),
+	)
+	reply, err := messenger.SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["result"] != Method.GasPrice {
+		t.Errorf("request was not rewritten, got %v", reply)
+	}
+	if timings != 2 || len(order) != 4 || order[0] != "outer" || order[1] != "timing" {
+		t.Errorf("unexpected interceptor runs %v", order)
+	}
+	for i := 0; i < 2; i++ {
+		if header := <-authorized; header != "Bearer secret" {
+			t.Errorf("attempt %d sent Authorization %q", i+1, header)
+		}
+	}
+}
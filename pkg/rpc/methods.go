@@ -1,7 +1,9 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -98,13 +100,169 @@ var Method = rpcEnumList{
 	GetDelegationsByValidator:           "hmy_getDelegationsByValidator",
 }
 
-// TODO Use Reflection here to avoid typing out the cases
+// ParamKind is the JSON type a method parameter has to be
+type ParamKind int
+
+// Kinds of parameter, ParamAny skips the check
+const (
+	ParamAny ParamKind = iota
+	ParamString
+	ParamBool
+	ParamNumber
+	ParamObject
+	ParamArray
+)
+
+var paramKindNames = map[ParamKind]string{
+	ParamAny:    "any",
+	ParamString: "string",
+	ParamBool:   "bool",
+	ParamNumber: "number",
+	ParamObject: "object",
+	ParamArray:  "array",
+}
+
+func (k ParamKind) String() string {
+	return paramKindNames[k]
+}
+
+// MethodSpec records the parameters a method takes, the ones past Required may be left out
+type MethodSpec struct {
+	Params   []ParamKind
+	Required int
+}
+
+var (
+	// ErrUnknownMethod is returned for methods missing from the registry
+	ErrUnknownMethod = errors.New("unknown RPC method")
+	// ErrBadParams is returned for calls whose parameters do not fit the method
+	ErrBadParams = errors.New("bad parameters for RPC method")
+)
+
+func exactly(params ...ParamKind) MethodSpec {
+	return MethodSpec{params, len(params)}
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[method]MethodSpec{
+		Method.GetShardingStructure:                exactly(),
+		Method.GetNodeMetadata:                     exactly(),
+		Method.GetLatestBlockHeader:                exactly(),
+		Method.GetBlockByHash:                      exactly(ParamString, ParamBool),
+		Method.GetBlockByNumber:                    exactly(ParamString, ParamBool),
+		Method.GetBlockTransactionCountByHash:      exactly(ParamString),
+		Method.GetBlockTransactionCountByNumber:    exactly(ParamString),
+		Method.GetCode:                             exactly(ParamString, ParamString),
+		Method.GetTransactionByBlockHashAndIndex:   exactly(ParamString, ParamString),
+		Method.GetTransactionByBlockNumberAndIndex: exactly(ParamString, ParamString),
+		Method.GetTransactionByHash:                exactly(ParamString),
+		Method.GetTransactionReceipt:               exactly(ParamString),
+		Method.Syncing:                             exactly(),
+		Method.PeerCount:                           exactly(),
+		Method.GetBalance:                          exactly(ParamString, ParamString),
+		Method.GetStorageAt:                        exactly(ParamString, ParamString, ParamString),
+		Method.GetTransactionCount:                 exactly(ParamString, ParamString),
+		Method.SendTransaction:                     exactly(ParamObject),
+		Method.SendRawTransaction:                  exactly(ParamString),
+		Method.Subscribe:                           {[]ParamKind{ParamString, ParamObject}, 1},
+		Method.GetPastLogs:                         exactly(ParamObject),
+		Method.GetWork:                             exactly(),
+		Method.GetProof:                            exactly(ParamString, ParamArray, ParamString),
+		Method.GetFilterChanges:                    exactly(ParamString),
+		Method.NewPendingTransactionFilter:         exactly(),
+		Method.NewBlockFilter:                      exactly(),
+		Method.NewFilter:                           exactly(ParamObject),
+		Method.Call:                                exactly(ParamObject, ParamString),
+		Method.EstimateGas:                         {[]ParamKind{ParamObject, ParamString}, 1},
+		Method.GasPrice:                            exactly(),
+		Method.BlockNumber:                         exactly(),
+		Method.UnSubscribe:                         exactly(ParamString),
+		Method.NetVersion:                          exactly(),
+		Method.ProtocolVersion:                     exactly(),
+		Method.SendRawStakingTransaction:           exactly(ParamString),
+		Method.GetActiveValidatorAddresses:         exactly(),
+		Method.GetAllValidatorAddresses:            exactly(),
+		Method.GetValidatorInformation:             exactly(ParamString),
+		Method.GetDelegationsByDelegator:           exactly(ParamString),
+		Method.GetDelegationsByValidator:           exactly(ParamString),
+	}
+)
+
+// RegisterMethod adds or overrides a method, e.g. one newer than this SDK
+func RegisterMethod(m method, spec MethodSpec) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry[m] = spec
+}
+
+// LookupMethod returns the registered spec of m
+func LookupMethod(m method) (MethodSpec, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	spec, known := registry[m]
+	return spec, known
+}
 
 // ValidatedMethod checks if given method is known
-func ValidatedMethod(m method) string {
-	switch m := method(m); m {
+func ValidatedMethod(m method) (string, error) {
+	if _, known := LookupMethod(m); !known {
+		return "", errors.Wrap(ErrUnknownMethod, m)
+	}
+	return string(m), nil
+}
+
+// knownCalls refuses an invocation with a method missing from the registry before
+// it is sent, RegisterMethod lets methods newer than this SDK through
+func knownCalls(inv *Invocation) error {
+	if inv.Calls == nil {
+		_, err := ValidatedMethod(inv.Method)
+		return err
+	}
+	for _, call := range inv.Calls {
+		if _, err := ValidatedMethod(call.Method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCall checks params, as decoded from JSON, against the registered spec of m
+func ValidateCall(m method, params []interface{}) error {
+	spec, known := LookupMethod(m)
+	if !known {
+		return errors.Wrap(ErrUnknownMethod, m)
+	}
+	if len(params) < spec.Required || len(params) > len(spec.Params) {
+		if spec.Required == len(spec.Params) {
+			return errors.Wrapf(ErrBadParams, "%s takes %d parameters, got %d", m, spec.Required, len(params))
+		}
+		return errors.Wrapf(ErrBadParams,
+			"%s takes %d to %d parameters, got %d", m, spec.Required, len(spec.Params), len(params),
+		)
+	}
+	for i, param := range params {
+		if want := spec.Params[i]; !want.matches(param) {
+			return errors.Wrapf(ErrBadParams, "%s parameter %d should be a %s", m, i+1, want)
+		}
+	}
+	return nil
+}
+
+func (k ParamKind) matches(param interface{}) bool {
+	switch param.(type) {
+	case string:
+		return k == ParamAny || k == ParamString
+	case bool:
+		return k == ParamAny || k == ParamBool
+	case json.Number, float64, int, int64, uint64:
+		return k == ParamAny || k == ParamNumber
+	case map[string]interface{}:
+		return k == ParamAny || k == ParamObject
+	case []interface{}:
+		return k == ParamAny || k == ParamArray
 	default:
-		return string(m)
+		return k == ParamAny
 	}
 }
 
@@ -174,47 +332,33 @@ const (
 	wrongChain               = "ChainID on node differs from received chainID"
 )
 
-// ErrorCodeToError lifts an untyped error code from RPC to Error value
+// ErrorCodeToError lifts an untyped error code from RPC to an *RPCError
 func ErrorCodeToError(message string, code float64) error {
-	return errors.Wrap(errors.New(message), codeToMessage(code))
+	return &RPCError{Code: int(code), Message: message}
+}
+
+var codeMessages = map[errorCode]string{
+	errorCodeEnumeration.rpcInvalidRequest:       invalidRequestError,
+	errorCodeEnumeration.rpcMethodNotFound:       methodNotFoundError,
+	errorCodeEnumeration.rpcInvalidParams:        invalidParamsError,
+	errorCodeEnumeration.rpcInternalError:        internalError,
+	errorCodeEnumeration.rpcParseError:           parseError,
+	errorCodeEnumeration.rpcMiscError:            miscError,
+	errorCodeEnumeration.rpcTypeError:            typeError,
+	errorCodeEnumeration.rpcInvalidAddressOrKey:  invalidAddressOrKeyError,
+	errorCodeEnumeration.rpcInvalidParameter:     invalidParameterError,
+	errorCodeEnumeration.rpcDatabaseError:        databaseError,
+	errorCodeEnumeration.rpcDeserializationError: deserializationError,
+	errorCodeEnumeration.rpcVerifyError:          verifyError,
+	errorCodeEnumeration.rpcVerifyRejected:       verifyRejectedError,
+	errorCodeEnumeration.rpcInWarmup:             rpcInWarmupError,
+	errorCodeEnumeration.rpcMethodDeprecated:     methodDeprecatedError,
+	errorCodeEnumeration.rpcIncorrectChainID:     wrongChain,
 }
 
-// TODO Use reflection here instead of typing out the cases or at least a map
 func codeToMessage(err float64) string {
-	switch e := errorCode(err); e {
-	case errorCodeEnumeration.rpcInvalidRequest:
-		return invalidRequestError
-	case errorCodeEnumeration.rpcMethodNotFound:
-		return methodNotFoundError
-	case errorCodeEnumeration.rpcInvalidParams:
-		return invalidParamsError
-	case errorCodeEnumeration.rpcInternalError:
-		return internalError
-	case errorCodeEnumeration.rpcParseError:
-		return parseError
-	case errorCodeEnumeration.rpcMiscError:
-		return miscError
-	case errorCodeEnumeration.rpcTypeError:
-		return typeError
-	case errorCodeEnumeration.rpcInvalidAddressOrKey:
-		return invalidAddressOrKeyError
-	case errorCodeEnumeration.rpcInvalidParameter:
-		return invalidParameterError
-	case errorCodeEnumeration.rpcDatabaseError:
-		return databaseError
-	case errorCodeEnumeration.rpcDeserializationError:
-		return deserializationError
-	case errorCodeEnumeration.rpcVerifyError:
-		return verifyError
-	case errorCodeEnumeration.rpcVerifyRejected:
-		return verifyRejectedError
-	case errorCodeEnumeration.rpcInWarmup:
-		return rpcInWarmupError
-	case errorCodeEnumeration.rpcMethodDeprecated:
-		return methodDeprecatedError
-	case errorCodeEnumeration.rpcIncorrectChainID:
-		return wrongChain
-	default:
-		panic(fmt.Sprintf("Error number %v not found", err))
+	if message, known := codeMessages[errorCode(err)]; known {
+		return message
 	}
+	return fmt.Sprintf("Unknown error code %v", err)
 }
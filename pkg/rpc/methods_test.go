@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestEveryMethodIsRegistered(t *testing.T) {
+	methods := reflect.ValueOf(Method)
+	for i := 0; i < methods.NumField(); i++ {
+		name := methods.Field(i).String()
+		if _, err := ValidatedMethod(name); err != nil {
+			t.Errorf("%s (%s) has no spec", methods.Type().Field(i).Name, name)
+		}
+	}
+}
+
+func TestValidateCall(t *testing.T) {
+	cases := []struct {
+		method string
+		params []interface{}
+		err    error
+	}{
+		{Method.GetBalance, []interface{}{"one1x", "latest"}, nil},
+		{Method.GetBalance, []interface{}{"one1x"}, ErrBadParams},
+		{Method.GetBlockByNumber, []interface{}{"0x10", "true"}, ErrBadParams},
+		{Method.Subscribe, []interface{}{"newHeads"}, nil},
+		{Method.Subscribe, []interface{}{"logs", map[string]interface{}{}}, nil},
+		{"hmy_notAMethod", []interface{}{}, ErrUnknownMethod},
+	}
+	for _, c := range cases {
+		if err := ValidateCall(c.method, c.params); errors.Cause(err) != c.err {
+			t.Errorf("%s %v: expected %v, got %v", c.method, c.params, c.err, err)
+		}
+	}
+	RegisterMethod("hmy_notAMethod", MethodSpec{[]ParamKind{ParamAny}, 0})
+	defer unregisterMethod("hmy_notAMethod")
+	if err := ValidateCall("hmy_notAMethod", []interface{}{}); err != nil {
+		t.Errorf("registered method was rejected: %v", err)
+	}
+}
+
+func unregisterMethod(m method) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	delete(registry, m)
+}
+
+func TestMessengersRefuseUnknownMethods(t *testing.T) {
+	server, hits := countingNode(t)
+	defer server.Close()
+	messenger := NewHTTPHandler(server.URL)
+	if _, err := messenger.SendRPC("hmy_notYetKnown", []interface{}{}); errors.Cause(err) != ErrUnknownMethod {
+		t.Errorf("expected an unknown method, got %v", err)
+	}
+	if _, err := messenger.SendBatch([]Call{{Method.BlockNumber, nil}, {"hmy_notYetKnown", nil}}); errors.Cause(err) != ErrUnknownMethod {
+		t.Errorf("expected the batch to be refused, got %v", err)
+	}
+	if _, err := Request("hmy_notYetKnown", server.URL, []interface{}{}); errors.Cause(err) != ErrUnknownMethod {
+		t.Errorf("expected Request to refuse the method, got %v", err)
+	}
+	if n := atomic.LoadInt32(hits); n != 0 {
+		t.Fatalf("%d unknown calls reached the node", n)
+	}
+
+	RegisterMethod("hmy_notYetKnown", exactly())
+	defer unregisterMethod("hmy_notYetKnown")
+	if _, err := messenger.SendRPC("hmy_notYetKnown", []interface{}{}); err != nil {
+		t.Errorf("registered method was not sent: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// poolRecheckAfter is how long an endpoint that failed is passed over before it gets traffic again
+const poolRecheckAfter = 15 * time.Second
+
+var (
+	// ErrNoEndpoints is returned by a PoolMessenger built without any node
+	ErrNoEndpoints = errors.New("no endpoints to send the call to")
+	// failover classifies errors worth trying on another endpoint, same rules as retrying
+	failover = RetryPolicy{
+		RetryableCodes:    []int{int(errorCodeEnumeration.rpcInWarmup)},
+		RetryServerErrors: true,
+	}
+)
+
+type poolEndpoint struct {
+	node      string
+	messenger ContextT
+	// downSince is zero while the endpoint is healthy
+	downSince time.Time
+	lastErr   error
+}
+
+// PoolMessenger spreads calls over several endpoints serving the same shard,
+// an endpoint that fails is skipped until it recovers and the call moves on to the next one
+type PoolMessenger struct {
+	lock       sync.Mutex
+	endpoints  []*poolEndpoint
+	roundRobin bool
+	next       int
+	retry      RetryPolicy
+}
+
+// NewPoolHandler returns a messenger over nodes, each reached according to its scheme,
+// calls go to the first healthy node in the given order
+func NewPoolHandler(nodes []string) *PoolMessenger {
+	pool := &PoolMessenger{}
+	for _, node := range nodes {
+		var messenger ContextT
+		if IsWebSocket(node) {
+			messenger = NewWSHandler(node)
+		} else {
+			messenger = NewHTTPHandler(node)
+		}
+		pool.endpoints = append(pool.endpoints, &poolEndpoint{node: node, messenger: messenger})
+	}
+	return pool
+}
+
+// SplitNodes reads a comma separated list of nodes, as accepted by --node
+func SplitNodes(nodes string) []string {
+	list := []string{}
+	for _, node := range strings.Split(nodes, ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			list = append(list, node)
+		}
+	}
+	return list
+}
+
+// WithInterceptors runs the calls to every endpoint through interceptors,
+// each endpoint sees its own attempts. Set them before first use
+func (M *PoolMessenger) WithInterceptors(interceptors ...Interceptor) *PoolMessenger {
+	for _, endpoint := range M.endpoints {
+		switch messenger := endpoint.messenger.(type) {
+		case *HTTPMessenger:
+			messenger.WithInterceptors(interceptors...)
+		case *WSMessenger:
+			messenger.WithInterceptors(interceptors...)
+		}
+	}
+	return M
+}
+
+// WithRateLimits makes the calls to each endpoint wait for its limiter in limits,
+// a throttled endpoint is failed over. Set them before first use
+func (M *PoolMessenger) WithRateLimits(limits *RateLimits) *PoolMessenger {
+	for _, endpoint := range M.endpoints {
+		switch messenger := endpoint.messenger.(type) {
+		case *HTTPMessenger:
+			messenger.WithRateLimiter(limits.For(endpoint.node))
+		case *WSMessenger:
+			messenger.WithRateLimiter(limits.For(endpoint.node))
+		}
+	}
+	return M
+}
+
+// WithRetryPolicy has each endpoint retry transient failures before the call
+// fails over to the next one, health checks are not retried. Set it before first use
+func (M *PoolMessenger) WithRetryPolicy(policy RetryPolicy) *PoolMessenger {
+	M.retry = policy
+	return M
+}
+
+// WithRoundRobin spreads reads over all healthy endpoints, writes keep going to
+// the first healthy one so nonces stay consistent, set it before first use
+func (M *PoolMessenger) WithRoundRobin(enabled bool) *PoolMessenger {
+	M.roundRobin = enabled
+	return M
+}
+
+// SendRPC sends the call to a healthy endpoint, failing over to the others
+func (M *PoolMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
+	return M.SendRPCContext(context.Background(), meth, params)
+}
+
+// SendRPCContext is SendRPC bounded by ctx
+func (M *PoolMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	var reply Reply
+	err := M.each(ctx, []string{meth}, func(endpoint *poolEndpoint) (err error) {
+		reply, err = endpoint.messenger.SendRPCContext(ctx, meth, params)
+		return err
+	})
+	return reply, err
+}
+
+// SendBatch sends the calls to a healthy endpoint, the batch fails over as a whole
+func (M *PoolMessenger) SendBatch(calls []Call) ([]BatchReply, error) {
+	return M.SendBatchContext(context.Background(), calls)
+}
+
+// SendBatchContext is SendBatch bounded by ctx
+func (M *PoolMessenger) SendBatchContext(ctx context.Context, calls []Call) ([]BatchReply, error) {
+	methods := make([]string, len(calls))
+	for i, call := range calls {
+		methods[i] = call.Method
+	}
+	var replies []BatchReply
+	err := M.each(ctx, methods, func(endpoint *poolEndpoint) (err error) {
+		replies, err = SendBatchContext(ctx, endpoint.messenger, calls)
+		return err
+	})
+	return replies, err
+}
+
+// HealthCheck asks every endpoint at once for its block number and marks it up or down,
+// it fails only when no endpoint answered
+func (M *PoolMessenger) HealthCheck(ctx context.Context) error {
+	if len(M.endpoints) == 0 {
+		return ErrNoEndpoints
+	}
+	var wg sync.WaitGroup
+	for _, endpoint := range M.endpoints {
+		wg.Add(1)
+		go func(endpoint *poolEndpoint) {
+			defer wg.Done()
+			_, err := endpoint.messenger.SendRPCContext(ctx, Method.BlockNumber, []interface{}{})
+			if ctx.Err() == nil {
+				M.mark(endpoint, err)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	for _, endpoint := range M.endpoints {
+		if endpoint.downSince.IsZero() {
+			return nil
+		}
+	}
+	return errors.Wrap(M.endpoints[0].lastErr, "no healthy endpoint")
+}
+
+// Healthy lists the endpoints currently taking traffic
+func (M *PoolMessenger) Healthy() []string {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	healthy := []string{}
+	for _, endpoint := range M.endpoints {
+		if endpoint.downSince.IsZero() {
+			healthy = append(healthy, endpoint.node)
+		}
+	}
+	return healthy
+}
+
+// Close closes the endpoints that keep a connection open
+func (M *PoolMessenger) Close() error {
+	var err error
+	for _, endpoint := range M.endpoints {
+		if ws, ok := endpoint.messenger.(*WSMessenger); ok {
+			if e := ws.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// each runs attempt on endpoints in order of preference until one succeeds,
+// an error that another endpoint would not fix is returned right away
+func (M *PoolMessenger) each(ctx context.Context, methods []string, attempt func(*poolEndpoint) error) error {
+	order := M.order(methods)
+	if len(order) == 0 {
+		return ErrNoEndpoints
+	}
+	var err error
+	for _, endpoint := range order {
+		err = M.retry.do(ctx, methods, func() error { return attempt(endpoint) })
+		if ctx.Err() != nil {
+			return err
+		}
+		if err == nil || !M.failsOver(methods, err) {
+			M.mark(endpoint, nil)
+			return err
+		}
+		M.mark(endpoint, err)
+	}
+	return err
+}
+
+func (M *PoolMessenger) failsOver(methods []string, err error) bool {
+	for _, method := range methods {
+		if !failover.retryable(method, err) {
+			return false
+		}
+	}
+	return true
+}
+
+// order puts healthy endpoints first, endpoints down for a while are given another
+// chance and the rest are kept as a last resort
+func (M *PoolMessenger) order(methods []string) []*poolEndpoint {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	start := 0
+	if M.roundRobin && len(M.endpoints) > 0 && !anyWrite(methods) {
+		start = M.next % len(M.endpoints)
+		M.next++
+	}
+	healthy, down := []*poolEndpoint{}, []*poolEndpoint{}
+	for i := range M.endpoints {
+		endpoint := M.endpoints[(start+i)%len(M.endpoints)]
+		if endpoint.downSince.IsZero() || time.Since(endpoint.downSince) > poolRecheckAfter {
+			healthy = append(healthy, endpoint)
+		} else {
+			down = append(down, endpoint)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (M *PoolMessenger) mark(endpoint *poolEndpoint, err error) {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	endpoint.lastErr = err
+	if err == nil {
+		endpoint.downSince = time.Time{}
+	} else {
+		endpoint.downSince = time.Now()
+	}
+}
+
+func anyWrite(methods []string) bool {
+	for _, method := range methods {
+		if isWrite(method) {
+			return true
+		}
+	}
+	return false
+}
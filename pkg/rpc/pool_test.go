@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolMessengerFailsOver(t *testing.T) {
+	down, downHits := flakyNode(t, 1000)
+	defer down.Close()
+	up, upHits := flakyNode(t, 0)
+	defer up.Close()
+	pool := NewPoolHandler([]string{down.URL, up.URL})
+
+	reply, err := pool.SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["result"] != Method.BlockNumber {
+		t.Errorf("unexpected reply %v", reply)
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0] != up.URL {
+		t.Errorf("expected only %s healthy, got %v", up.URL, healthy)
+	}
+	// The failed endpoint is passed over until it is due for a recheck
+	if _, err := pool.SendRPC(Method.GasPrice, []interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(downHits); n != 1 {
+		t.Errorf("failed endpoint was hit %d times", n)
+	}
+	if n := atomic.LoadInt32(upHits); n != 2 {
+		t.Errorf("healthy endpoint was hit %d times", n)
+	}
+	if err := pool.HealthCheck(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPoolMessengerKeepsAmbiguousWritesInPlace(t *testing.T) {
+	down, _ := flakyNode(t, 1000)
+	defer down.Close()
+	up, upHits := flakyNode(t, 0)
+	defer up.Close()
+	pool := NewPoolHandler([]string{down.URL, up.URL})
+
+	if _, err := pool.SendRPC(Method.SendRawTransaction, []interface{}{"0x00"}); err == nil {
+		t.Fatal("write should have failed with the first endpoint")
+	}
+	if n := atomic.LoadInt32(upHits); n != 0 {
+		t.Errorf("write was sent again to the second endpoint")
+	}
+}
+
+func TestPoolMessengerRetriesEndpointBeforeFailingOver(t *testing.T) {
+	flaky, flakyHits := flakyNode(t, 1)
+	defer flaky.Close()
+	up, upHits := flakyNode(t, 0)
+	defer up.Close()
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryServerErrors: true}
+	pool := NewPoolHandler([]string{flaky.URL, up.URL}).WithRetryPolicy(policy)
+
+	if _, err := pool.SendRPC(Method.BlockNumber, []interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(flakyHits); n != 2 {
+		t.Errorf("flaky endpoint was hit %d times, expected a retry", n)
+	}
+	if n := atomic.LoadInt32(upHits); n != 0 {
+		t.Errorf("call failed over though the retry succeeded")
+	}
+	if healthy := pool.Healthy(); len(healthy) != 2 {
+		t.Errorf("expected both endpoints healthy, got %v", healthy)
+	}
+}
@@ -1,78 +1,213 @@
 package rpc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
 
 	"github.com/harmony-one/go-sdk/pkg/common"
 )
 
 var (
-	queryID = 0
+	// queryID numbers the calls of every messenger in the process, use nextQueryID
+	queryID uint64
 	post    = []byte("POST")
+	// ErrIDMismatch is returned when a node answers with the id of another call
+	ErrIDMismatch = errors.New("reply id does not match the request id")
+	// defaultHTTPClient is what fasthttp.Do would use, shared by package level calls
+	defaultHTTPClient = &fasthttp.Client{}
 )
 
-func baseRequest(method string, node string, params interface{}) ([]byte, error) {
+func nextQueryID() uint64 {
+	return atomic.AddUint64(&queryID, 1)
+}
+
+func baseRequest(ctx context.Context, method string, node string, params interface{}) ([]byte, error) {
+	return requestVia(ctx, chain(nil, httpSender(defaultHTTPClient)), method, node, params)
+}
+
+// requestVia sends a single call down send
+func requestVia(ctx context.Context, send Invoker, method string, node string, params interface{}) ([]byte, error) {
+	invocation := &Invocation{Node: node, Method: method, Params: params, Header: http.Header{}}
+	return send(ctx, invocation)
+}
+
+// httpSender is the end of every HTTP chain, ids of a batch are handed back in inv.ids
+// to match the replies, the reply to a single call has to carry its id
+func httpSender(client *fasthttp.Client) Invoker {
+	return func(ctx context.Context, inv *Invocation) ([]byte, error) {
+		return sendHTTP(ctx, client, inv)
+	}
+}
+
+func sendHTTP(ctx context.Context, client *fasthttp.Client, inv *Invocation) ([]byte, error) {
+	if err := knownCalls(inv); err != nil {
+		return nil, err
+	}
+	if inv.Calls != nil {
+		inv.ids = make([]string, len(inv.Calls))
+		payload := make([]map[string]interface{}, len(inv.Calls))
+		for i, call := range inv.Calls {
+			id := nextQueryID()
+			inv.ids[i] = strconv.FormatUint(id, 10)
+			payload[i] = map[string]interface{}{
+				"jsonrpc": common.JSONRPCVersion,
+				"id":      id,
+				"method":  call.Method,
+				"params":  call.Params,
+			}
+		}
+		requestBody, _ := json.Marshal(payload)
+		return postJSON(ctx, client, inv.Node, inv.Header, requestBody)
+	}
+	id := nextQueryID()
 	requestBody, _ := json.Marshal(map[string]interface{}{
 		"jsonrpc": common.JSONRPCVersion,
-		"id":      strconv.Itoa(queryID),
-		"method":  method,
-		"params":  params,
+		"id":      id,
+		"method":  inv.Method,
+		"params":  inv.Params,
 	})
+	rawReply, err := postJSON(ctx, client, inv.Node, inv.Header, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReplyID(rawReply, strconv.FormatUint(id, 10)); err != nil {
+		return nil, err
+	}
+	return rawReply, nil
+}
+
+// checkReplyID makes sure rawReply answers the call numbered id, a node that
+// could not make out the call at all answers with a null id and an error
+func checkReplyID(rawReply []byte, id string) error {
+	routing := envelope{}
+	if json.Unmarshal(rawReply, &routing) != nil {
+		return nil
+	}
+	got := replyID(routing.ID)
+	if got == id || (got == "" && routing.Error != nil) {
+		return nil
+	}
+	return errors.Wrapf(ErrIDMismatch, "sent %s, received %s", id, routing.ID)
+}
+
+// replyID is the id of a JSON-RPC message as text whether it was sent as a
+// number or a string, "" when it has none
+func replyID(raw json.RawMessage) string {
+	id := strings.Trim(string(bytes.TrimSpace(raw)), `"`)
+	if id == "null" {
+		return ""
+	}
+	return id
+}
+
+// postJSON sends an already encoded JSON-RPC payload, single call or batch,
+// giving up once ctx is done
+func postJSON(
+	ctx context.Context, client *fasthttp.Client, node string, header http.Header, requestBody []byte,
+) ([]byte, error) {
 	const contentType = "application/json"
 	req := fasthttp.AcquireRequest()
 	req.SetBody(requestBody)
 	req.Header.SetMethodBytes(post)
+	for key, values := range header {
+		req.Header.Set(key, strings.Join(values, ", "))
+	}
 	req.Header.SetContentType(contentType)
 	req.SetRequestURIBytes([]byte(node))
 	res := fasthttp.AcquireResponse()
-	if err := fasthttp.Do(req, res); err != nil {
-		return nil, err
+	release := func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
 	}
+	done := make(chan error, 1)
+	go func() {
+		if deadline, ok := ctx.Deadline(); ok {
+			done <- client.DoDeadline(req, res, deadline)
+			return
+		}
+		done <- client.Do(req, res)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			release()
+			if _, hasDeadline := ctx.Deadline(); hasDeadline && err == fasthttp.ErrTimeout {
+				// fasthttp may notice the deadline a moment before ctx does
+				<-ctx.Done()
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+	case <-ctx.Done():
+		// fasthttp cannot abort a request in flight, recycle it once it lands
+		go func() {
+			<-done
+			release()
+		}()
+		return nil, ctx.Err()
+	}
+	defer release()
 	c := res.StatusCode()
 	if c != 200 {
-		return nil, fmt.Errorf("http status code not 200, received: %d", c)
+		status := &HTTPStatusError{StatusCode: c}
+		if c == http.StatusTooManyRequests {
+			status.RetryAfter = parseRetryAfter(string(res.Header.Peek("Retry-After")), time.Now())
+		}
+		return nil, status
 	}
-	fasthttp.ReleaseRequest(req)
 	body := res.Body()
 	result := make([]byte, len(body))
 	copy(result, body)
-	fasthttp.ReleaseResponse(res)
-	if common.DebugRPC {
-		reqB := common.JSONPrettyFormat(string(requestBody))
-		respB := common.JSONPrettyFormat(string(body))
-		fmt.Printf("URL: %s, Request Body: %s\n\n", node, reqB)
-		fmt.Printf("URL: %s, Response Body: %s\n\n", node, respB)
-	}
-	queryID++
 	return result, nil
 }
 
-// TODO Check if Method known, return error when not known, good intern task
-
-// Request processes
+// Request sends one call to node, methods missing from the registry are refused with ErrUnknownMethod.
+// Numbers in the Reply are json.Number
 func Request(method string, node string, params interface{}) (Reply, error) {
-	rpcJSON := make(map[string]interface{})
-	rawReply, err := baseRequest(method, node, params)
+	return RequestContext(context.Background(), method, node, params)
+}
+
+// RequestContext is Request bounded by ctx
+func RequestContext(ctx context.Context, method string, node string, params interface{}) (Reply, error) {
+	rawReply, err := baseRequest(ctx, method, node, params)
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal(rawReply, &rpcJSON)
+	return liftReply(rawReply)
+}
+
+// liftReply turns a raw JSON-RPC response into a Reply, or the error it carries,
+// numbers are kept as json.Number as documented on Reply
+func liftReply(rawReply []byte) (Reply, error) {
+	rpcJSON := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(rawReply))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rpcJSON); err != nil {
+		return nil, errors.Wrap(err, "could not decode reply")
+	}
 	if oops := rpcJSON["error"]; oops != nil {
-		errNo := oops.(map[string]interface{})["code"].(float64)
-		errMessage := ""
-		if oops.(map[string]interface{})["message"] != nil {
-			errMessage = oops.(map[string]interface{})["message"].(string)
-		}
-		return nil, ErrorCodeToError(errMessage, errNo)
+		return nil, liftRPCError(oops)
 	}
 	return rpcJSON, nil
 }
 
 // RawRequest is to sidestep the lifting done by Request
 func RawRequest(method string, node string, params interface{}) ([]byte, error) {
-	return baseRequest(method, node, params)
+	return baseRequest(context.Background(), method, node, params)
+}
+
+// RawRequestContext is RawRequest bounded by ctx
+func RawRequestContext(ctx context.Context, method string, node string, params interface{}) ([]byte, error) {
+	return baseRequest(ctx, method, node, params)
 }
@@ -1,10 +1,56 @@
 package rpc
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestRPCRequest(t *testing.T) {
 	fmt.Println("hell rpc?")
 }
+
+func TestRequestContextGivesUpOnHungNode(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := NewHTTPHandler(server.URL).SendRPCContext(ctx, Method.BlockNumber, []interface{}{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call returned after %s, deadline was not honored", elapsed)
+	}
+}
+
+func TestRequestFailsOnBodyThatIsNotJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>gateway</html>"))
+	}))
+	defer server.Close()
+	if reply, err := Request(Method.BlockNumber, server.URL, []interface{}{}); err == nil {
+		t.Errorf("expected a decoding error, got reply %v", reply)
+	}
+}
+
+func TestReplyKeepsLargeNumbersExact(t *testing.T) {
+	reply, err := liftReply([]byte(`{"jsonrpc":"2.0","id":1,"result":{"nonce":18446744073709551615}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := reply["result"].(map[string]interface{})["nonce"]
+	if nonce != json.Number("18446744073709551615") {
+		t.Errorf("nonce decoded as %#v", nonce)
+	}
+}
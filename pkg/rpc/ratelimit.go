@@ -0,0 +1,177 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket refilled with PerSecond tokens a second and
+// holding at most Burst of them, PerSecond of 0 means no limit
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// RateLimiter spaces out the calls sharing it according to a RateLimit, and
+// holds them all back while a throttling node asked to be left alone
+type RateLimiter struct {
+	rate   float64
+	burst  float64
+	lock   sync.Mutex
+	tokens float64
+	// last is when tokens was brought up to date, in the future while paused
+	last time.Time
+}
+
+// NewRateLimiter returns a limiter starting with a full bucket, a Burst below 1 counts as 1
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	burst := math.Max(float64(limit.Burst), 1)
+	return &RateLimiter{rate: limit.PerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait takes a token, waiting for one if the bucket is empty, or fails once ctx is done
+func (L *RateLimiter) Wait(ctx context.Context) error {
+	delay := L.reserve()
+	for delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			L.release()
+			return ctx.Err()
+		}
+		// A pause may have started while waiting
+		delay = L.held()
+	}
+	return nil
+}
+
+// Pause holds back every call for d, as asked by the Retry-After of a 429
+func (L *RateLimiter) Pause(d time.Duration) {
+	L.lock.Lock()
+	defer L.lock.Unlock()
+	L.refill(time.Now())
+	if until := time.Now().Add(d); until.After(L.last) {
+		L.last = until
+		// No burst right after being throttled
+		L.tokens = math.Min(L.tokens, 1)
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (L *RateLimiter) reserve() time.Duration {
+	L.lock.Lock()
+	defer L.lock.Unlock()
+	now := time.Now()
+	if L.rate <= 0 {
+		return L.last.Sub(now)
+	}
+	L.refill(now)
+	L.tokens--
+	delay := L.last.Sub(now)
+	if L.tokens < 0 {
+		delay += time.Duration(-L.tokens / L.rate * float64(time.Second))
+	}
+	return delay
+}
+
+// release gives back the token of a call that gave up waiting
+func (L *RateLimiter) release() {
+	L.lock.Lock()
+	defer L.lock.Unlock()
+	if L.rate > 0 {
+		L.tokens = math.Min(L.tokens+1, L.burst)
+	}
+}
+
+func (L *RateLimiter) held() time.Duration {
+	L.lock.Lock()
+	defer L.lock.Unlock()
+	return L.last.Sub(time.Now())
+}
+
+// refill adds the tokens earned since last, caller must hold L.lock
+func (L *RateLimiter) refill(now time.Time) {
+	if !now.After(L.last) {
+		return
+	}
+	if L.rate > 0 {
+		L.tokens = math.Min(L.burst, L.tokens+now.Sub(L.last).Seconds()*L.rate)
+	}
+	L.last = now
+}
+
+// RateLimitInterceptor makes every attempt wait for limiter, a 429 answer
+// pauses limiter for as long as the Retry-After of the node asks
+func RateLimitInterceptor(limiter *RateLimiter) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) ([]byte, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		reply, err := next(ctx, inv)
+		if pause := retryAfter(err); pause > 0 {
+			limiter.Pause(pause)
+		}
+		return reply, err
+	}
+}
+
+// RateLimits hands out one limiter per endpoint, shared by all the messengers
+// reaching it. Endpoints are told apart by host, PerHost overrides Default
+type RateLimits struct {
+	Default  RateLimit
+	PerHost  map[string]RateLimit
+	lock     sync.Mutex
+	limiters map[string]*RateLimiter
+}
+
+// For returns the limiter of the endpoint node, nil when it is not limited
+func (L *RateLimits) For(node string) *RateLimiter {
+	host := node
+	if parsed, err := url.Parse(node); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	limit, ok := L.PerHost[host]
+	if !ok {
+		limit = L.Default
+	}
+	if limit.PerSecond <= 0 {
+		return nil
+	}
+	L.lock.Lock()
+	defer L.lock.Unlock()
+	if L.limiters == nil {
+		L.limiters = make(map[string]*RateLimiter)
+	}
+	limiter, ok := L.limiters[host]
+	if !ok {
+		limiter = NewRateLimiter(limit)
+		L.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// Limit makes messenger, the messenger of node, wait for the limiter of its
+// endpoint. Messengers of other kinds are returned as they are
+func (L *RateLimits) Limit(node string, messenger T) T {
+	switch limited := messenger.(type) {
+	case *HTTPMessenger:
+		return limited.WithRateLimiter(L.For(node))
+	case *WSMessenger:
+		return limited.WithRateLimiter(L.For(node))
+	case *PoolMessenger:
+		return limited.WithRateLimits(L)
+	}
+	return messenger
+}
+
+// Dial limits the messengers handed out by dial
+func (L *RateLimits) Dial(dial Dialer) Dialer {
+	return func(node string) T {
+		return L.Limit(node, dial(node))
+	}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesCalls(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{PerSecond: 50, Burst: 2})
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	// The burst goes right away, the 10 other calls come 20ms apart
+	if took := time.Since(start); took < 180*time.Millisecond {
+		t.Errorf("12 calls at 50 a second with a burst of 2 took only %s", took)
+	}
+}
+
+func TestRateLimiterGivesUpWithContext(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{PerSecond: 1, Burst: 1})
+	limiter.Wait(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline, got %v", err)
+	}
+}
+
+func TestRateLimitsShareLimiterPerHost(t *testing.T) {
+	limits := &RateLimits{
+		Default: RateLimit{PerSecond: 10},
+		PerHost: map[string]RateLimit{"api.s1.hmny.io": {}},
+	}
+	if limits.For("https://api.s0.hmny.io") != limits.For("wss://api.s0.hmny.io") {
+		t.Error("messengers of the same host got different limiters")
+	}
+	if limits.For("https://api.s0.hmny.io") == limits.For("https://api.s2.hmny.io") {
+		t.Error("different hosts share a limiter")
+	}
+	if limits.For("https://api.s1.hmny.io") != nil {
+		t.Error("host without a limit got a limiter")
+	}
+}
+
+func TestThrottledEndpointPausesItsMessengers(t *testing.T) {
+	hits := new(int32)
+	node := echoHandler(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		node(w, r)
+	}))
+	defer server.Close()
+	limits := &RateLimits{Default: RateLimit{PerSecond: 1000, Burst: 10}}
+	throttled := limits.Limit(server.URL, NewHTTPHandler(server.URL)).(*HTTPMessenger)
+	if _, err := throttled.SendRPC(Method.BlockNumber, []interface{}{}); retryAfter(err) != time.Second {
+		t.Fatalf("expected the 429 back, got %v", err)
+	}
+	start := time.Now()
+	other := limits.Limit(server.URL, NewHTTPHandler(server.URL)).(*HTTPMessenger)
+	if _, err := other.SendRPC(Method.BlockNumber, []interface{}{"0x1"}); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < 900*time.Millisecond {
+		t.Errorf("endpoint called again after %s despite Retry-After of 1s", took)
+	}
+}
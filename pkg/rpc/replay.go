@@ -0,0 +1,209 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotRecorded is returned by a Replayer for calls missing from its fixture
+	ErrNotRecorded = errors.New("call not found in recorded fixture")
+)
+
+// Dialer hands out the messenger used to reach a node, NewHandler is the live one
+type Dialer func(node string) T
+
+// Interaction is one recorded call and its outcome, Failure holds errors that
+// did not come from the node itself, e.g. a refused connection
+type Interaction struct {
+	Node    string          `json:"node"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Reply   json.RawMessage `json:"reply,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	Failure string          `json:"failure,omitempty"`
+}
+
+// Recorder keeps every call made through its handlers so Save can write them out as a fixture
+type Recorder struct {
+	lock         sync.Mutex
+	dial         Dialer
+	interactions []Interaction
+}
+
+// NewRecorder records calls sent through the messengers dial hands out
+func NewRecorder(dial Dialer) *Recorder {
+	return &Recorder{dial: dial}
+}
+
+// Handler is a Dialer recording the calls sent to node
+func (R *Recorder) Handler(node string) T {
+	return &recordingMessenger{R, node, R.dial(node)}
+}
+
+// Save writes the calls recorded so far to path
+func (R *Recorder) Save(path string) error {
+	R.lock.Lock()
+	defer R.lock.Unlock()
+	fixture, err := json.MarshalIndent(R.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, fixture, 0644)
+}
+
+func (R *Recorder) record(node, meth string, params []interface{}, reply Reply, err error) {
+	encodedParams, _ := json.Marshal(params)
+	interaction := Interaction{Node: node, Method: meth, Params: encodedParams}
+	switch e := errors.Cause(err).(type) {
+	case nil:
+		interaction.Reply, _ = json.Marshal(reply)
+	case *RPCError:
+		interaction.Error = e
+	default:
+		interaction.Failure = err.Error()
+	}
+	R.lock.Lock()
+	R.interactions = append(R.interactions, interaction)
+	R.lock.Unlock()
+}
+
+type recordingMessenger struct {
+	recorder *Recorder
+	node     string
+	live     T
+}
+
+func (M *recordingMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
+	return M.SendRPCContext(context.Background(), meth, params)
+}
+
+func (M *recordingMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	reply, err := SendRPCContext(ctx, M.live, meth, params)
+	M.recorder.record(M.node, meth, params, reply, err)
+	return reply, err
+}
+
+func (M *recordingMessenger) SendBatch(calls []Call) ([]BatchReply, error) {
+	return M.SendBatchContext(context.Background(), calls)
+}
+
+// SendBatchContext records each call of the batch on its own, a batch that failed as a whole is not recorded
+func (M *recordingMessenger) SendBatchContext(ctx context.Context, calls []Call) ([]BatchReply, error) {
+	replies, err := SendBatchContext(ctx, M.live, calls)
+	if err != nil {
+		return nil, err
+	}
+	for i, call := range calls {
+		M.recorder.record(M.node, call.Method, call.Params, replies[i].Reply, replies[i].Error)
+	}
+	return replies, nil
+}
+
+// Close closes the live messenger when it holds a connection
+func (M *recordingMessenger) Close() error {
+	if ws, ok := M.live.(*WSMessenger); ok {
+		return ws.Close()
+	}
+	return nil
+}
+
+// Replayer answers calls from a fixture written by a Recorder, identical calls
+// get their recorded outcomes back in the order they were recorded
+type Replayer struct {
+	lock    sync.Mutex
+	pending map[string][]Interaction
+}
+
+// LoadReplayer reads the fixture at path
+func LoadReplayer(path string) (*Replayer, error) {
+	fixture, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	interactions := []Interaction{}
+	if err := json.Unmarshal(fixture, &interactions); err != nil {
+		return nil, errors.Wrapf(err, "could not decode fixture %s", path)
+	}
+	R := &Replayer{pending: make(map[string][]Interaction)}
+	for _, interaction := range interactions {
+		key := replayKey(interaction.Node, interaction.Method, interaction.Params)
+		R.pending[key] = append(R.pending[key], interaction)
+	}
+	return R, nil
+}
+
+// Handler is a Dialer replaying the calls recorded against node
+func (R *Replayer) Handler(node string) T {
+	return &replayingMessenger{R, node}
+}
+
+// Exhausted reports if every recorded call has been replayed
+func (R *Replayer) Exhausted() bool {
+	R.lock.Lock()
+	defer R.lock.Unlock()
+	for _, queue := range R.pending {
+		if len(queue) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (R *Replayer) replay(node, meth string, params []interface{}) (Reply, error) {
+	encodedParams, _ := json.Marshal(params)
+	key := replayKey(node, meth, encodedParams)
+	R.lock.Lock()
+	queue := R.pending[key]
+	if len(queue) == 0 {
+		R.lock.Unlock()
+		return nil, errors.Wrapf(ErrNotRecorded, "%s %s %s", node, meth, encodedParams)
+	}
+	interaction := queue[0]
+	R.pending[key] = queue[1:]
+	R.lock.Unlock()
+	switch {
+	case interaction.Error != nil:
+		rpcErr := *interaction.Error
+		return nil, &rpcErr
+	case interaction.Failure != "":
+		return nil, errors.New(interaction.Failure)
+	}
+	reply := Reply{}
+	decoder := json.NewDecoder(bytes.NewReader(interaction.Reply))
+	decoder.UseNumber()
+	if err := decoder.Decode(&reply); err != nil {
+		return nil, errors.Wrap(err, "could not decode recorded reply")
+	}
+	return reply, nil
+}
+
+// replayKey compacts params so fixtures edited by hand still match
+func replayKey(node, meth string, params json.RawMessage) string {
+	compact := &bytes.Buffer{}
+	if json.Compact(compact, params) != nil {
+		compact.Write(params)
+	}
+	return node + " " + meth + " " + compact.String()
+}
+
+type replayingMessenger struct {
+	replayer *Replayer
+	node     string
+}
+
+func (M *replayingMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
+	return M.replayer.replay(M.node, meth, params)
+}
+
+func (M *replayingMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return M.SendRPC(meth, params)
+}
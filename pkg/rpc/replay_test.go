@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	server := cannedNode(t, map[string]string{
+		Method.BlockNumber: `"0x10"`,
+		Method.GasPrice:    `"0x1"`,
+	})
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "fixture.json")
+
+	recorder := NewRecorder(func(node string) T { return NewHTTPHandler(node) })
+	live := recorder.Handler(server.URL)
+	if _, err := live.SendRPC(Method.BlockNumber, []interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := live.SendRPC(Method.GasPrice, []interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Save(fixture); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	replayer, err := LoadReplayer(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := replayer.Handler(server.URL)
+	reply, err := replayed.SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil || reply["result"] != "0x10" {
+		t.Errorf("unexpected replay %v %v", reply, err)
+	}
+	if reply, err := replayed.SendRPC(Method.GasPrice, []interface{}{}); err != nil || reply["result"] != "0x1" {
+		t.Errorf("unexpected replay %v %v", reply, err)
+	}
+	if !replayer.Exhausted() {
+		t.Error("every recorded call should have been replayed")
+	}
+	if _, err := replayed.SendRPC(Method.BlockNumber, []interface{}{}); errors.Cause(err) != ErrNotRecorded {
+		t.Errorf("expected ErrNotRecorded once the fixture ran out, got %v", err)
+	}
+}
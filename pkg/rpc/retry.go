@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+// RetryPolicy decides which failed calls are attempted again and how long to wait in between.
+// The zero value never retries.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt too, below 2 nothing is retried
+	MaxAttempts int
+	// InitialBackoff is the pause before the second attempt, it doubles up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableCodes are JSON-RPC error codes meaning the node did not act on the call
+	RetryableCodes []int
+	// RetryServerErrors retries HTTP 5xx answers, never applied to writes
+	RetryServerErrors bool
+}
+
+// DefaultRetryPolicy rides out short node restarts and warm up
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       4,
+	InitialBackoff:    250 * time.Millisecond,
+	MaxBackoff:        4 * time.Second,
+	RetryableCodes:    []int{int(errorCodeEnumeration.rpcInWarmup)},
+	RetryServerErrors: true,
+}
+
+// HTTPStatusError is returned when a node answers with anything but 200
+type HTTPStatusError struct {
+	StatusCode int
+	// RetryAfter is how long a throttling node asked to be left alone, zero if it did not say
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("http status code not 200, received: %d, retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("http status code not 200, received: %d", e.StatusCode)
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// retryAfter is the pause a throttling node asked for in err, zero if none
+func retryAfter(err error) time.Duration {
+	if status, ok := errors.Cause(err).(*HTTPStatusError); ok && status.StatusCode == http.StatusTooManyRequests {
+		return status.RetryAfter
+	}
+	return 0
+}
+
+// isWrite marks methods that change chain state, repeating one after an
+// ambiguous failure could broadcast the same intent twice
+func isWrite(method string) bool {
+	switch method {
+	case Method.SendTransaction, Method.SendRawTransaction, Method.SendRawStakingTransaction:
+		return true
+	default:
+		return false
+	}
+}
+
+// neverSent reports failures where the call provably did not reach the node
+func neverSent(err error) bool {
+	if err == fasthttp.ErrDialTimeout {
+		return true
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// connectionFailure reports transport failures where the call may or may not have been processed
+func connectionFailure(err error) bool {
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF, fasthttp.ErrConnectionClosed, fasthttp.ErrTimeout, ErrWSConnectionLost:
+		return true
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+	if err == syscall.ECONNRESET || err == syscall.EPIPE {
+		return true
+	}
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func (p RetryPolicy) retryable(method string, err error) bool {
+	err = errors.Cause(err)
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if neverSent(err) {
+		return true
+	}
+	if rpcErr, ok := err.(*RPCError); ok {
+		for _, code := range p.RetryableCodes {
+			if code == rpcErr.Code {
+				return true
+			}
+		}
+		return false
+	}
+	// A throttled call was turned away before the node looked at it, even a write
+	if status, ok := err.(*HTTPStatusError); ok && status.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if isWrite(method) {
+		return false
+	}
+	if status, ok := err.(*HTTPStatusError); ok {
+		return p.RetryServerErrors && status.StatusCode >= 500
+	}
+	return connectionFailure(err)
+}
+
+// do runs attempt until it succeeds, fails for good, ctx is done or the attempts run out,
+// a node answering 429 is left alone for as long as its Retry-After asks
+func (p RetryPolicy) do(ctx context.Context, methods []string, attempt func() error) error {
+	backoff := p.InitialBackoff
+	for tries := 1; ; tries++ {
+		err := attempt()
+		if err == nil || tries >= p.MaxAttempts {
+			return err
+		}
+		for _, method := range methods {
+			if !p.retryable(method, err) {
+				return err
+			}
+		}
+		pause := backoff
+		if asked := retryAfter(err); asked > pause {
+			pause = asked
+		}
+		select {
+		case <-time.After(pause):
+		case <-ctx.Done():
+			return err
+		}
+		if backoff *= 2; p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
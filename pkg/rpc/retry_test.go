@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyNode answers 503 to the first failures requests, then echoes the method back
+func flakyNode(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	hits := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		call := map[string]interface{}{}
+		if err := json.Unmarshal(body, &call); err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": call["id"], "result": call["method"],
+		})
+	}))
+	return server, hits
+}
+
+func TestRetryPolicyRetriesReads(t *testing.T) {
+	server, hits := flakyNode(t, 2)
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, RetryServerErrors: true}
+	reply, err := NewHTTPHandler(server.URL).WithRetryPolicy(policy).SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["result"] != Method.BlockNumber {
+		t.Errorf("unexpected reply %v", reply)
+	}
+	if n := atomic.LoadInt32(hits); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetryPolicySkipsAmbiguousWrites(t *testing.T) {
+	server, hits := flakyNode(t, 1)
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, RetryServerErrors: true}
+	_, err := NewHTTPHandler(server.URL).WithRetryPolicy(policy).SendRPC(Method.SendRawTransaction, []interface{}{"0x00"})
+	if status, ok := err.(*HTTPStatusError); !ok || status.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the 503 back, got %v", err)
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("write was sent %d times", n)
+	}
+}
+
+func TestRetryPolicyWaitsOutRetryAfter(t *testing.T) {
+	hits := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(hits, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		call := map[string]interface{}{}
+		json.Unmarshal(body, &call)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": call["id"], "result": call["method"],
+		})
+	}))
+	defer server.Close()
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	start := time.Now()
+	// A throttled write never reached the node, so it is safe to send again
+	_, err := NewHTTPHandler(server.URL).WithRetryPolicy(policy).SendRPC(Method.SendRawTransaction, []interface{}{"0x00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < time.Second {
+		t.Errorf("retried after %s despite Retry-After of 1s", took)
+	}
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for value, expected := range map[string]time.Duration{
+		"120":                           2 * time.Minute,
+		"Wed, 01 Jan 2020 00:00:30 GMT": 30 * time.Second,
+		"Tue, 31 Dec 2019 23:59:00 GMT": 0,
+		"soon":                          0,
+	} {
+		if got := parseRetryAfter(value, now); got != expected {
+			t.Errorf("Retry-After %q read as %s, expected %s", value, got, expected)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+const subscriptionQueueSize = 256
+
+var (
+	// ErrSubscriptionQueueOverflow ends a subscription whose reader fell too far behind
+	ErrSubscriptionQueueOverflow = errors.New("subscription queue overflow, notifications not read fast enough")
+)
+
+// Header is the block header pushed by a newHeads subscription
+type Header struct {
+	Hash             string         `json:"hash"`
+	ParentHash       string         `json:"parentHash"`
+	Miner            string         `json:"miner"`
+	StateRoot        string         `json:"stateRoot"`
+	TransactionsRoot string         `json:"transactionsRoot"`
+	ReceiptsRoot     string         `json:"receiptsRoot"`
+	LogsBloom        string         `json:"logsBloom"`
+	Number           hexutil.Uint64 `json:"number"`
+	GasLimit         hexutil.Uint64 `json:"gasLimit"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Timestamp        hexutil.Uint64 `json:"timestamp"`
+	ExtraData        string         `json:"extraData"`
+	MixHash          string         `json:"mixHash"`
+}
+
+// Log is a contract event, as pushed by a logs subscription
+type Log struct {
+	Address          string         `json:"address"`
+	Topics           []string       `json:"topics"`
+	Data             string         `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        string         `json:"blockHash"`
+	TransactionHash  string         `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+// FilterQuery selects logs by emitting contract and topics, a nil entry in
+// Topics matches any topic at that position and several entries are OR'd
+type FilterQuery struct {
+	Address   []string   `json:"address,omitempty"`
+	Topics    [][]string `json:"topics,omitempty"`
+	FromBlock string     `json:"fromBlock,omitempty"`
+	ToBlock   string     `json:"toBlock,omitempty"`
+	BlockHash string     `json:"blockHash,omitempty"`
+}
+
+// Subscription is a live hmy_subscribe feed, survives reconnects of its WSMessenger
+type Subscription struct {
+	messenger *WSMessenger
+	params    []interface{}
+	id        string
+	queue     chan json.RawMessage
+	err       chan error
+	quit      chan struct{}
+	once      sync.Once
+}
+
+// Err delivers the reason the subscription ended on its own, it is closed
+// without a value after Unsubscribe
+func (S *Subscription) Err() <-chan error {
+	return S.err
+}
+
+// Unsubscribe stops the feed and closes its notification channel
+func (S *Subscription) Unsubscribe() error {
+	M := S.messenger
+	M.lock.Lock()
+	id := S.id
+	delete(M.subs, id)
+	live := M.conn != nil
+	M.lock.Unlock()
+	S.end(nil)
+	if !live {
+		return nil
+	}
+	_, err := M.SendRPC(Method.UnSubscribe, []interface{}{id})
+	return err
+}
+
+// SubscribeNewHeads pushes every new block header the node accepts
+func (M *WSMessenger) SubscribeNewHeads() (<-chan *Header, *Subscription, error) {
+	out := make(chan *Header)
+	sub, err := M.subscribe([]interface{}{"newHeads"}, func(raw json.RawMessage, quit chan struct{}) {
+		header := &Header{}
+		if json.Unmarshal(raw, header) != nil {
+			return
+		}
+		select {
+		case out <- header:
+		case <-quit:
+		}
+	}, func() { close(out) })
+	return out, sub, err
+}
+
+// SubscribeLogs pushes every log matching q
+func (M *WSMessenger) SubscribeLogs(q FilterQuery) (<-chan *Log, *Subscription, error) {
+	out := make(chan *Log)
+	sub, err := M.subscribe([]interface{}{"logs", q}, func(raw json.RawMessage, quit chan struct{}) {
+		log := &Log{}
+		if json.Unmarshal(raw, log) != nil {
+			return
+		}
+		select {
+		case out <- log:
+		case <-quit:
+		}
+	}, func() { close(out) })
+	return out, sub, err
+}
+
+// SubscribePendingTransactions pushes the hash of every transaction entering the pool
+func (M *WSMessenger) SubscribePendingTransactions() (<-chan string, *Subscription, error) {
+	out := make(chan string)
+	sub, err := M.subscribe([]interface{}{"newPendingTransactions"}, func(raw json.RawMessage, quit chan struct{}) {
+		hash := ""
+		if json.Unmarshal(raw, &hash) != nil {
+			return
+		}
+		select {
+		case out <- hash:
+		case <-quit:
+		}
+	}, func() { close(out) })
+	return out, sub, err
+}
+
+// subscribe opens the feed, deliver runs for each notification off the read loop
+// and done runs once the feed is over
+func (M *WSMessenger) subscribe(
+	params []interface{}, deliver func(json.RawMessage, chan struct{}), done func(),
+) (*Subscription, error) {
+	sub := &Subscription{
+		messenger: M,
+		params:    params,
+		queue:     make(chan json.RawMessage, subscriptionQueueSize),
+		err:       make(chan error, 1),
+		quit:      make(chan struct{}),
+	}
+	rawReply, err := M.send(context.Background(), Method.Subscribe, params, sub)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := liftReply(rawReply); err != nil {
+		return nil, err
+	}
+	go func() {
+		defer done()
+		for {
+			select {
+			case raw := <-sub.queue:
+				deliver(raw, sub.quit)
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (S *Subscription) push(raw json.RawMessage) {
+	select {
+	case S.queue <- raw:
+	default:
+		M := S.messenger
+		M.lock.Lock()
+		delete(M.subs, S.id)
+		M.lock.Unlock()
+		S.end(ErrSubscriptionQueueOverflow)
+	}
+}
+
+func (S *Subscription) end(reason error) {
+	S.once.Do(func() {
+		if reason != nil {
+			S.err <- reason
+		}
+		close(S.err)
+		close(S.quit)
+	})
+}
+
+func (S *Subscription) ended() bool {
+	select {
+	case <-S.quit:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+)
+
+const proxyDialTimeout = 10 * time.Second
+
+var (
+	// ErrInvalidNodeURL is returned for node addresses an HTTPMessenger cannot reach
+	ErrInvalidNodeURL = errors.New("invalid node url")
+)
+
+// HTTPOptions tunes the connections of an HTTPMessenger, the zero value is
+// what fasthttp uses by default
+type HTTPOptions struct {
+	// CAFile is a PEM bundle trusted on top of the system roots
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its key, for nodes asking for one
+	CertFile string
+	KeyFile  string
+	// TLS is the base TLS configuration, CAFile and CertFile are added to a copy of it
+	TLS *tls.Config
+	// Proxy is an http:// or https:// proxy, reached with CONNECT, user info is sent as basic auth
+	Proxy string
+	// Header is sent with every request, e.g. an API key
+	Header http.Header
+	// MaxConnsPerHost caps the connections open to the node
+	MaxConnsPerHost int
+	// IdleConnTimeout closes kept alive connections unused for that long
+	IdleConnTimeout time.Duration
+	// ReadTimeout and WriteTimeout bound each response read and request write
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// checkHTTPURL makes sure node is an absolute http:// or https:// url
+func checkHTTPURL(node string) error {
+	parsed, err := url.Parse(node)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidNodeURL, "%s: %s", node, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.Wrapf(ErrInvalidNodeURL, "%s: scheme must be http or https", node)
+	}
+	if parsed.Host == "" {
+		return errors.Wrapf(ErrInvalidNodeURL, "%s: no host", node)
+	}
+	return nil
+}
+
+// client builds the fasthttp client following options
+func (options HTTPOptions) client() (*fasthttp.Client, error) {
+	client := &fasthttp.Client{
+		MaxConnsPerHost:     options.MaxConnsPerHost,
+		MaxIdleConnDuration: options.IdleConnTimeout,
+		ReadTimeout:         options.ReadTimeout,
+		WriteTimeout:        options.WriteTimeout,
+	}
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	client.TLSConfig = tlsConfig
+	if options.Proxy != "" {
+		proxy, err := url.Parse(options.Proxy)
+		if err != nil || (proxy.Scheme != "http" && proxy.Scheme != "https") || proxy.Host == "" {
+			return nil, errors.Errorf("invalid proxy %s, expected http://host:port or https://host:port", options.Proxy)
+		}
+		client.Dial = dialThrough(proxy)
+	}
+	return client, nil
+}
+
+func (options HTTPOptions) tlsConfig() (*tls.Config, error) {
+	if options.CAFile == "" && options.CertFile == "" && options.TLS == nil {
+		return nil, nil
+	}
+	config := &tls.Config{}
+	if options.TLS != nil {
+		config = options.TLS.Clone()
+	}
+	if options.CAFile != "" {
+		bundle, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read CA bundle")
+		}
+		if config.RootCAs == nil {
+			if config.RootCAs, err = x509.SystemCertPool(); err != nil {
+				config.RootCAs = x509.NewCertPool()
+			}
+		}
+		if !config.RootCAs.AppendCertsFromPEM(bundle) {
+			return nil, errors.Errorf("no certificate found in %s", options.CAFile)
+		}
+	}
+	if options.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		config.Certificates = append(config.Certificates, certificate)
+	}
+	return config, nil
+}
+
+// dialThrough opens connections tunneled by proxy, TLS to the node is layered on top by fasthttp
+func dialThrough(proxy *url.URL) fasthttp.DialFunc {
+	return func(addr string) (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: proxyDialTimeout}
+		var conn net.Conn
+		var err error
+		if proxy.Scheme == "https" {
+			conn, err = tls.DialWithDialer(dialer, "tcp", proxyAddr(proxy), &tls.Config{ServerName: proxy.Hostname()})
+		} else {
+			conn, err = dialer.Dial("tcp", proxyAddr(proxy))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not reach proxy %s", proxy.Host)
+		}
+		connect := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+		if proxy.User != nil {
+			password, _ := proxy.User.Password()
+			credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+			connect += "Proxy-Authorization: Basic " + credentials + "\r\n"
+		}
+		conn.SetDeadline(time.Now().Add(proxyDialTimeout))
+		if _, err := conn.Write([]byte(connect + "\r\n")); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		// The proxy says nothing more until the node does, nothing is left buffered past its answer
+		response, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrapf(err, "could not tunnel through proxy %s", proxy.Host)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			conn.Close()
+			return nil, errors.Errorf("proxy %s refused tunnel to %s: %s", proxy.Host, addr, response.Status)
+		}
+		conn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
+
+func proxyAddr(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	if proxy.Scheme == "https" {
+		return net.JoinHostPort(proxy.Hostname(), "443")
+	}
+	return net.JoinHostPort(proxy.Hostname(), "80")
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNewHTTPHandlerRejectsBadURLs(t *testing.T) {
+	for _, node := range []string{"localhost:9500", "ftp://localhost:9500", "http://", "://x"} {
+		if _, err := NewHTTPHandler(node).SendRPC(Method.BlockNumber, []interface{}{}); errors.Cause(err) != ErrInvalidNodeURL {
+			t.Errorf("%s: expected ErrInvalidNodeURL, got %v", node, err)
+		}
+		if _, err := NewHTTPHandlerWithOptions(node, HTTPOptions{}); errors.Cause(err) != ErrInvalidNodeURL {
+			t.Errorf("%s: expected ErrInvalidNodeURL from options, got %v", node, err)
+		}
+	}
+}
+
+func TestHTTPOptionsTrustCAFileAndSendHeaders(t *testing.T) {
+	echo := echoHandler(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		echo(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPHandler(server.URL).SendRPC(Method.BlockNumber, []interface{}{}); err == nil {
+		t.Fatal("self signed node trusted without its CA")
+	}
+	bundle, err := ioutil.TempFile("", "ca-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bundle.Name())
+	pem.Encode(bundle, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	bundle.Close()
+
+	messenger, err := NewHTTPHandlerWithOptions(server.URL, HTTPOptions{
+		CAFile: bundle.Name(),
+		Header: http.Header{"X-Api-Key": []string{"secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := messenger.SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["result"] != Method.BlockNumber {
+		t.Errorf("unexpected reply %v", reply)
+	}
+}
@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Block is a block as returned by hmy_getBlockByNumber and hmy_getBlockByHash,
+// Transactions only carry their Hash unless the full transactions were asked for
+type Block struct {
+	Number           hexutil.Uint64 `json:"number"`
+	Hash             string         `json:"hash"`
+	ParentHash       string         `json:"parentHash"`
+	Nonce            Quantity       `json:"nonce"`
+	MixHash          string         `json:"mixHash"`
+	LogsBloom        string         `json:"logsBloom"`
+	StateRoot        string         `json:"stateRoot"`
+	TransactionsRoot string         `json:"transactionsRoot"`
+	ReceiptsRoot     string         `json:"receiptsRoot"`
+	Miner            string         `json:"miner"`
+	Difficulty       json.Number    `json:"difficulty"`
+	ExtraData        string         `json:"extraData"`
+	Size             hexutil.Uint64 `json:"size"`
+	GasLimit         hexutil.Uint64 `json:"gasLimit"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Timestamp        hexutil.Uint64 `json:"timestamp"`
+	Transactions     []Transaction  `json:"transactions"`
+	Uncles           []string       `json:"uncles"`
+}
+
+// Quantity is a number the node may send as a plain JSON number or as 0x hex,
+// Harmony nodes send the nonce of a block as a plain number
+type Quantity uint64
+
+// MarshalJSON writes q as a plain number, the way Harmony nodes do
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uint64(q))
+}
+
+// UnmarshalJSON takes either form
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return (*hexutil.Uint64)(q).UnmarshalJSON(data)
+	}
+	return json.Unmarshal(data, (*uint64)(q))
+}
+
+// CallArgs describes a call for hmy_estimateGas and hmy_call, left out fields are chosen by the node
+type CallArgs struct {
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	Gas      *hexutil.Uint64 `json:"gas,omitempty"`
+	GasPrice *hexutil.Big    `json:"gasPrice,omitempty"`
+	Value    *hexutil.Big    `json:"value,omitempty"`
+	Data     hexutil.Bytes   `json:"data,omitempty"`
+}
+
+// Transaction is a plain transfer or contract call, BlockHash and BlockNumber
+// are nil while it is pending
+type Transaction struct {
+	BlockHash        *string         `json:"blockHash"`
+	BlockNumber      *hexutil.Uint64 `json:"blockNumber"`
+	From             string          `json:"from"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Hash             string          `json:"hash"`
+	Input            string          `json:"input"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	To               *string         `json:"to"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	Value            *hexutil.Big    `json:"value"`
+	ShardID          uint32          `json:"shardID"`
+	ToShardID        uint32          `json:"toShardID"`
+	V                string          `json:"v"`
+	R                string          `json:"r"`
+	S                string          `json:"s"`
+}
+
+// UnmarshalJSON also takes a bare transaction hash, the way blocks list them by default
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	hash := ""
+	if json.Unmarshal(data, &hash) == nil {
+		*t = Transaction{Hash: hash}
+		return nil
+	}
+	type plain Transaction
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// Receipt is the outcome of an executed transaction
+type Receipt struct {
+	BlockHash         string          `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	ContractAddress   *string         `json:"contractAddress"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	From              string          `json:"from"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	Logs              []*Log          `json:"logs"`
+	LogsBloom         string          `json:"logsBloom"`
+	ShardID           uint32          `json:"shardID"`
+	Status            *hexutil.Uint64 `json:"status"`
+	Root              string          `json:"root"`
+	To                string          `json:"to"`
+	TransactionHash   string          `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+}
+
+// Delegation is a stake placed on a validator
+type Delegation struct {
+	DelegatorAddress string         `json:"delegator-address"`
+	Amount           *big.Int       `json:"amount"`
+	Reward           *big.Int       `json:"reward"`
+	Undelegations    []Undelegation `json:"undelegations"`
+}
+
+// Undelegation is stake on its way back to the delegator
+type Undelegation struct {
+	Amount *big.Int `json:"amount"`
+	Epoch  *big.Int `json:"epoch"`
+}
+
+// ValidatorInformation is a validator as returned by hmy_getValidatorInformation,
+// rates are kept as the decimal strings the node sends
+type ValidatorInformation struct {
+	Address            string       `json:"address"`
+	BLSPublicKeys      []string     `json:"bls-public-keys"`
+	MinSelfDelegation  *big.Int     `json:"min-self-delegation"`
+	MaxTotalDelegation *big.Int     `json:"max-total-delegation"`
+	Rate               string       `json:"rate"`
+	MaxRate            string       `json:"max-rate"`
+	MaxChangeRate      string       `json:"max-change-rate"`
+	UpdateHeight       *big.Int     `json:"update-height"`
+	CreationHeight     *big.Int     `json:"creation-height"`
+	Name               string       `json:"name"`
+	Identity           string       `json:"identity"`
+	Website            string       `json:"website"`
+	SecurityContact    string       `json:"security-contact"`
+	Details            string       `json:"details"`
+	Delegations        []Delegation `json:"delegations"`
+}
+
+// NodeMetadata describes the node answering hmy_getNodeMetadata, ChainID is
+// the decimal chain id it signs for
+type NodeMetadata struct {
+	BLSPublicKey string `json:"blskey"`
+	Version      string `json:"version"`
+	NetworkType  string `json:"network"`
+	ChainID      string `json:"chainid"`
+	IsLeader     bool   `json:"is-leader"`
+	ShardID      uint32 `json:"shard-id"`
+	NodeRole     string `json:"role"`
+}
+
+// ChainIDValue is ChainID as a number, nil when the node did not report one
+func (m *NodeMetadata) ChainIDValue() *big.Int {
+	value, ok := new(big.Int).SetString(m.ChainID, 0)
+	if !ok {
+		return nil
+	}
+	return value
+}
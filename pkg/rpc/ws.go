@@ -0,0 +1,435 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+)
+
+const (
+	wsRedialMinBackoff = time.Second
+	wsRedialMaxBackoff = 30 * time.Second
+)
+
+var (
+	// ErrWSClosed is returned for calls made on, or pending in, a closed WSMessenger
+	ErrWSClosed = errors.New("websocket messenger is closed")
+	// ErrWSConnectionLost is returned for calls still waiting when the connection dropped
+	ErrWSConnectionLost = errors.New("websocket connection lost before reply arrived")
+)
+
+// envelope is the subset of a JSON-RPC message needed to route it
+type envelope struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// DefaultWSCallTimeout bounds the calls of a WSMessenger made with a context without deadline
+const DefaultWSCallTimeout = 30 * time.Second
+
+// wsCall is a call waiting for its reply, sub is set when the call is an hmy_subscribe
+type wsCall struct {
+	wait chan wsReply
+	sub  *Subscription
+}
+
+// wsReply is the message answering a call, or why none will
+type wsReply struct {
+	message []byte
+	err     error
+}
+
+// WSMessenger keeps one WebSocket connection open to a node and multiplexes
+// calls over it, a dropped connection is redialed on the next call
+type WSMessenger struct {
+	node         string
+	lock         sync.Mutex
+	writeMu      sync.Mutex
+	conn         *websocket.Conn
+	pending      map[string]wsCall
+	subs         map[string]*Subscription
+	retry        RetryPolicy
+	interceptors []Interceptor
+	callTimeout  time.Duration
+	// abandoned are the ids of calls that gave up, their late replies are dropped
+	abandoned map[string]struct{}
+	// dialing is closed once the dial in progress, if any, is over
+	dialing   chan struct{}
+	onDrop    func(node string, err error)
+	redialing bool
+	closed    bool
+}
+
+// NewWSHandler returns a messenger for a ws:// or wss:// node, dialing is
+// deferred until the first call
+func NewWSHandler(node string) *WSMessenger {
+	return &WSMessenger{
+		node:        node,
+		pending:     make(map[string]wsCall),
+		abandoned:   make(map[string]struct{}),
+		subs:        make(map[string]*Subscription),
+		callTimeout: DefaultWSCallTimeout,
+	}
+}
+
+// IsWebSocket reports if the endpoint should be reached with a WSMessenger
+func IsWebSocket(node string) bool {
+	return strings.HasPrefix(node, "ws://") || strings.HasPrefix(node, "wss://")
+}
+
+// NewHandler returns a messenger suited to the scheme of node
+func NewHandler(node string) T {
+	if IsWebSocket(node) {
+		return NewWSHandler(node)
+	}
+	return NewHTTPHandler(node)
+}
+
+// SendRPC sends the call over the shared connection and waits for the reply with the same id
+func (M *WSMessenger) SendRPC(meth string, params []interface{}) (Reply, error) {
+	return M.SendRPCContext(context.Background(), meth, params)
+}
+
+// SendRPCContext is SendRPC bounded by ctx, the connection stays up when ctx
+// ends early and a late reply is discarded
+func (M *WSMessenger) SendRPCContext(ctx context.Context, meth string, params []interface{}) (Reply, error) {
+	var reply Reply
+	err := M.retry.do(ctx, []string{meth}, func() error {
+		rawReply, err := M.send(ctx, meth, params, nil)
+		if err != nil {
+			return err
+		}
+		reply, err = liftReply(rawReply)
+		return err
+	})
+	return reply, err
+}
+
+// WithRetryPolicy makes the messenger retry transient failures, set it before first use
+func (M *WSMessenger) WithRetryPolicy(policy RetryPolicy) *WSMessenger {
+	M.retry = policy
+	return M
+}
+
+// WithInterceptors runs every call through interceptors, the first one
+// outermost, on each attempt. Headers set by them are not sent over WebSocket.
+// Set them before first use
+func (M *WSMessenger) WithInterceptors(interceptors ...Interceptor) *WSMessenger {
+	M.interceptors = append(M.interceptors, interceptors...)
+	return M
+}
+
+// WithRateLimiter makes every attempt wait for limiter, which may be shared
+// with other messengers, nil leaves calls unlimited. Set it before first use
+func (M *WSMessenger) WithRateLimiter(limiter *RateLimiter) *WSMessenger {
+	if limiter != nil {
+		M.interceptors = append(M.interceptors, RateLimitInterceptor(limiter))
+	}
+	return M
+}
+
+// WithCallTimeout bounds calls made with a context without deadline by timeout,
+// 0 lets them wait as long as their context. Set it before first use
+func (M *WSMessenger) WithCallTimeout(timeout time.Duration) *WSMessenger {
+	M.callTimeout = timeout
+	return M
+}
+
+// WithDropObserver has observe told of every connection lost other than by
+// Close, along with why. Set it before first use
+func (M *WSMessenger) WithDropObserver(observe func(node string, err error)) *WSMessenger {
+	M.onDrop = observe
+	return M
+}
+
+// send runs the call through the interceptors before the round trip
+func (M *WSMessenger) send(
+	ctx context.Context, meth string, params []interface{}, sub *Subscription,
+) ([]byte, error) {
+	invocation := &Invocation{Node: M.node, Method: meth, Params: params, Header: http.Header{}}
+	return chain(M.interceptors, func(ctx context.Context, inv *Invocation) ([]byte, error) {
+		return M.roundTrip(ctx, inv.Method, inv.Params, sub)
+	})(ctx, invocation)
+}
+
+// Close tears down the connection, calls still waiting get ErrWSClosed and
+// live subscriptions end with ErrWSClosed on their Err channel
+func (M *WSMessenger) Close() error {
+	M.lock.Lock()
+	M.closed = true
+	subs := M.subs
+	M.subs = make(map[string]*Subscription)
+	var err error
+	if M.conn != nil {
+		err = M.conn.Close()
+		M.dropLocked(M.conn)
+	}
+	M.lock.Unlock()
+	for _, sub := range subs {
+		sub.end(ErrWSClosed)
+	}
+	return err
+}
+
+func (M *WSMessenger) roundTrip(
+	ctx context.Context, meth string, params interface{}, sub *Subscription,
+) ([]byte, error) {
+	if _, err := ValidatedMethod(meth); err != nil {
+		return nil, err
+	}
+	if _, bounded := ctx.Deadline(); !bounded && M.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, M.callTimeout)
+		defer cancel()
+	}
+	id := nextQueryID()
+	key := strconv.FormatUint(id, 10)
+	conn, wait, err := M.register(ctx, key, sub)
+	if err != nil {
+		return nil, err
+	}
+	requestBody, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": common.JSONRPCVersion,
+		"id":      id,
+		"method":  meth,
+		"params":  params,
+	})
+	M.writeMu.Lock()
+	// A write stuck on a dead peer must not outlive the call
+	deadline, _ := ctx.Deadline()
+	conn.SetWriteDeadline(deadline)
+	err = conn.WriteMessage(websocket.TextMessage, requestBody)
+	M.writeMu.Unlock()
+	if err != nil {
+		M.lock.Lock()
+		delete(M.pending, key)
+		dropped := M.dropLocked(conn)
+		M.lock.Unlock()
+		M.observeDrop(dropped, err)
+		return nil, err
+	}
+	var reply wsReply
+	var ok bool
+	select {
+	case reply, ok = <-wait:
+	case <-ctx.Done():
+		M.lock.Lock()
+		if _, waiting := M.pending[key]; waiting {
+			delete(M.pending, key)
+			M.abandoned[key] = struct{}{}
+		}
+		M.lock.Unlock()
+		return nil, ctx.Err()
+	}
+	if !ok {
+		M.lock.Lock()
+		defer M.lock.Unlock()
+		if M.closed {
+			return nil, ErrWSClosed
+		}
+		return nil, ErrWSConnectionLost
+	}
+	return reply.message, reply.err
+}
+
+// register reserves the reply slot of id, dialing if there is no live connection
+func (M *WSMessenger) register(
+	ctx context.Context, id string, sub *Subscription,
+) (*websocket.Conn, chan wsReply, error) {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	for M.conn == nil {
+		if M.closed {
+			return nil, nil, ErrWSClosed
+		}
+		if err := M.dialLocked(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	if M.closed {
+		return nil, nil, ErrWSClosed
+	}
+	wait := make(chan wsReply, 1)
+	M.pending[id] = wsCall{wait, sub}
+	return M.conn, wait, nil
+}
+
+// dialLocked dials the node with M.lock released, so a slow dial holds back
+// neither Close nor the replies of other calls. A call finding a dial under way
+// waits for it instead. Caller must hold M.lock, which is held again on return
+func (M *WSMessenger) dialLocked(ctx context.Context) error {
+	if dialing := M.dialing; dialing != nil {
+		M.lock.Unlock()
+		defer M.lock.Lock()
+		select {
+		case <-dialing:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	dialing := make(chan struct{})
+	M.dialing = dialing
+	M.lock.Unlock()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, M.node, nil)
+	M.lock.Lock()
+	M.dialing = nil
+	close(dialing)
+	if err != nil {
+		return errors.Wrapf(err, "could not dial %s", M.node)
+	}
+	if M.closed {
+		conn.Close()
+		return ErrWSClosed
+	}
+	M.conn = conn
+	go M.readLoop(conn)
+	return nil
+}
+
+func (M *WSMessenger) readLoop(conn *websocket.Conn) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			M.lock.Lock()
+			dropped := M.dropLocked(conn)
+			M.lock.Unlock()
+			M.observeDrop(dropped, err)
+			return
+		}
+		M.dispatch(message)
+	}
+}
+
+func (M *WSMessenger) dispatch(message []byte) {
+	response := envelope{}
+	if err := json.Unmarshal(message, &response); err != nil {
+		return
+	}
+	id := replyID(response.ID)
+	if id == "" && strings.HasSuffix(response.Method, "_subscription") {
+		M.lock.Lock()
+		sub, ok := M.subs[response.Params.Subscription]
+		M.lock.Unlock()
+		if ok {
+			sub.push(response.Params.Result)
+		}
+		return
+	}
+	if id == "" && response.Error == nil {
+		return
+	}
+	M.lock.Lock()
+	call, ok := M.pending[id]
+	if !ok {
+		_, late := M.abandoned[id]
+		delete(M.abandoned, id)
+		if late {
+			M.lock.Unlock()
+			return
+		}
+		// The call this answers cannot be told, fail every waiting one rather than leave it hanging
+		waiting := M.pending
+		M.pending = make(map[string]wsCall)
+		M.lock.Unlock()
+		for _, call := range waiting {
+			call.wait <- wsReply{err: errors.Wrapf(ErrIDMismatch, "received %s", response.ID)}
+		}
+		return
+	}
+	delete(M.pending, id)
+	// A subscription must be routable before the next message is read,
+	// the node may push a notification right behind this reply
+	if subID, isString := response.Result.(string); ok && isString && call.sub != nil && !call.sub.ended() {
+		delete(M.subs, call.sub.id)
+		call.sub.id = subID
+		M.subs[subID] = call.sub
+	}
+	M.lock.Unlock()
+	call.wait <- wsReply{message: message}
+}
+
+// dropLocked forgets conn and fails every waiting call, it reports whether conn
+// was still the live connection. Caller must hold M.lock
+func (M *WSMessenger) dropLocked(conn *websocket.Conn) bool {
+	if M.conn != conn {
+		return false
+	}
+	conn.Close()
+	M.conn = nil
+	for id, call := range M.pending {
+		close(call.wait)
+		delete(M.pending, id)
+	}
+	M.abandoned = make(map[string]struct{})
+	if len(M.subs) > 0 && !M.closed && !M.redialing {
+		M.redialing = true
+		go M.resubscribe()
+	}
+	return true
+}
+
+func (M *WSMessenger) observeDrop(dropped bool, err error) {
+	if dropped && M.onDrop != nil {
+		M.onDrop(M.node, err)
+	}
+}
+
+// resubscribe redials with backoff and renews every live subscription,
+// notifications published while disconnected are lost
+func (M *WSMessenger) resubscribe() {
+	backoff := wsRedialMinBackoff
+	for {
+		M.lock.Lock()
+		subs := make([]*Subscription, 0, len(M.subs))
+		for _, sub := range M.subs {
+			subs = append(subs, sub)
+		}
+		if M.closed || len(subs) == 0 {
+			M.redialing = false
+			M.lock.Unlock()
+			return
+		}
+		M.lock.Unlock()
+		failed := false
+		for _, sub := range subs {
+			rawReply, err := M.send(context.Background(), Method.Subscribe, sub.params, sub)
+			if err != nil {
+				failed = true
+				break
+			}
+			if _, err := liftReply(rawReply); err != nil {
+				M.lock.Lock()
+				delete(M.subs, sub.id)
+				M.lock.Unlock()
+				sub.end(err)
+			}
+		}
+		M.lock.Lock()
+		if !failed && M.conn != nil {
+			M.redialing = false
+			M.lock.Unlock()
+			return
+		}
+		M.lock.Unlock()
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > wsRedialMaxBackoff {
+			backoff = wsRedialMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// echoNode answers every call with its method name, dropping the connection after drop calls
+func echoNode(t *testing.T, drop int) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for served := 0; drop == 0 || served < drop; served++ {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			call := map[string]interface{}{}
+			json.Unmarshal(message, &call)
+			reply, _ := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0", "id": call["id"], "result": call["method"],
+			})
+			conn.WriteMessage(websocket.TextMessage, reply)
+		}
+	}))
+}
+
+func waitForDrop(messenger *WSMessenger) {
+	for dropped := false; !dropped; time.Sleep(time.Millisecond) {
+		messenger.lock.Lock()
+		dropped = messenger.conn == nil
+		messenger.lock.Unlock()
+	}
+}
+
+func TestWSMessengerReconnects(t *testing.T) {
+	server := echoNode(t, 1)
+	defer server.Close()
+	drops := make(chan string, 3)
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http")).
+		WithDropObserver(func(node string, err error) { drops <- node })
+	defer messenger.Close()
+
+	for _, method := range []string{Method.BlockNumber, Method.PeerCount, Method.GasPrice} {
+		reply, err := messenger.SendRPC(method, []interface{}{})
+		if err != nil {
+			t.Fatalf("%s failed: %s", method, err)
+		}
+		if reply["result"] != method {
+			t.Errorf("reply routed to wrong call, got %v for %s", reply["result"], method)
+		}
+		waitForDrop(messenger)
+		select {
+		case node := <-drops:
+			if node != messenger.node {
+				t.Errorf("drop reported for %s", node)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("drop was not reported")
+		}
+	}
+}
+
+func TestWSMessengerClosed(t *testing.T) {
+	server := echoNode(t, 0)
+	defer server.Close()
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http"))
+	messenger.Close()
+	if _, err := messenger.SendRPC(Method.BlockNumber, []interface{}{}); err != ErrWSClosed {
+		t.Errorf("expected ErrWSClosed, got %v", err)
+	}
+}
+
+func TestWSMessengerClosesDuringDial(t *testing.T) {
+	// A node accepting connections but never answering the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	messenger := NewWSHandler("ws://" + listener.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	failed := make(chan error, 1)
+	go func() {
+		_, err := messenger.SendRPCContext(ctx, Method.BlockNumber, []interface{}{})
+		failed <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		messenger.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close waited for the dial")
+	}
+	cancel()
+	if err := <-failed; err == nil {
+		t.Error("call succeeded without a node")
+	}
+}
+
+func TestWSSubscriptionPendingTransactions(t *testing.T) {
+	hashes := []string{"0xaa", "0xbb"}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			call := map[string]interface{}{}
+			json.Unmarshal(message, &call)
+			reply, _ := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0", "id": call["id"], "result": "0x1",
+			})
+			conn.WriteMessage(websocket.TextMessage, reply)
+			if call["method"] != Method.Subscribe {
+				continue
+			}
+			for _, hash := range hashes {
+				notification, _ := json.Marshal(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "hmy_subscription",
+					"params":  map[string]interface{}{"subscription": "0x1", "result": hash},
+				})
+				conn.WriteMessage(websocket.TextMessage, notification)
+			}
+		}
+	}))
+	defer server.Close()
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer messenger.Close()
+
+	feed, sub, err := messenger.SubscribePendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range hashes {
+		if hash := <-feed; hash != expected {
+			t.Errorf("expected %s, got %s", expected, hash)
+		}
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatal(err)
+	}
+	if _, open := <-feed; open {
+		t.Error("feed still open after Unsubscribe")
+	}
+	if err := <-sub.Err(); err != nil {
+		t.Errorf("unexpected error after Unsubscribe: %s", err)
+	}
+}
+
+// wsNode hands every call to answer along with the connection to write the reply on
+func wsNode(t *testing.T, answer func(conn *websocket.Conn, call map[string]interface{})) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			call := map[string]interface{}{}
+			json.Unmarshal(message, &call)
+			answer(conn, call)
+		}
+	}))
+}
+
+func TestWSMessengerFailsCallsOnUnmatchedReply(t *testing.T) {
+	server := wsNode(t, func(conn *websocket.Conn, call map[string]interface{}) {
+		reply, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": "elsewhere", "result": "0x1"})
+		conn.WriteMessage(websocket.TextMessage, reply)
+	})
+	defer server.Close()
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer messenger.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := messenger.SendRPC(Method.BlockNumber, []interface{}{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if errors.Cause(err) != ErrIDMismatch {
+			t.Errorf("expected ErrIDMismatch, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call is still waiting for a reply that went to no one")
+	}
+}
+
+func TestWSMessengerDropsLateReplies(t *testing.T) {
+	server := wsNode(t, func(conn *websocket.Conn, call map[string]interface{}) {
+		if call["method"] == Method.PeerCount {
+			time.Sleep(100 * time.Millisecond)
+		}
+		reply, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": call["id"], "result": call["method"]})
+		conn.WriteMessage(websocket.TextMessage, reply)
+	})
+	defer server.Close()
+	messenger := NewWSHandler("ws" + strings.TrimPrefix(server.URL, "http")).WithCallTimeout(20 * time.Millisecond)
+	defer messenger.Close()
+
+	// No context deadline, the call timeout of the messenger applies
+	if _, err := messenger.SendRPC(Method.PeerCount, []interface{}{}); err != context.DeadlineExceeded {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+	// The late reply to it must not fail the next call
+	time.Sleep(150 * time.Millisecond)
+	reply, err := messenger.SendRPC(Method.BlockNumber, []interface{}{})
+	if err != nil || reply["result"] != Method.BlockNumber {
+		t.Errorf("unexpected reply %v %v", reply, err)
+	}
+}
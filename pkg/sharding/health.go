@@ -0,0 +1,119 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+// ShardHealth is how the endpoint of one shard is doing, Error is set when it
+// could not be asked and the other fields are then left empty
+type ShardHealth struct {
+	ShardID  int    `json:"shard-id"`
+	Endpoint string `json:"endpoint"`
+	Height   uint64 `json:"height"`
+	Peers    uint64 `json:"peers"`
+	Syncing  bool   `json:"syncing"`
+	// BlocksBehind is how far the node is from the highest block it knows of while syncing
+	BlocksBehind uint64 `json:"blocks-behind"`
+	// HeaderTime is when the latest block was proposed, zero if the node did not say
+	HeaderTime time.Time `json:"header-time"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// HeaderAge is how long ago the latest block was proposed, zero when unknown
+func (h ShardHealth) HeaderAge(now time.Time) time.Duration {
+	if h.HeaderTime.IsZero() {
+		return 0
+	}
+	return now.Sub(h.HeaderTime)
+}
+
+// Health asks the endpoint of every shard of the network node belongs to how it is doing
+func Health(ctx context.Context, node string) ([]ShardHealth, error) {
+	return HealthUsing(ctx, rpc.NewHandler, node)
+}
+
+// HealthUsing is Health reaching nodes through the messengers of dial, it only
+// fails when the sharding structure is unavailable, a shard that does not
+// answer has its Error set
+func HealthUsing(ctx context.Context, dial rpc.Dialer, node string) ([]ShardHealth, error) {
+	shards, err := StructureUsing(ctx, dial, node)
+	if err != nil {
+		return nil, err
+	}
+	report := make([]ShardHealth, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard RPCRoutes) {
+			defer wg.Done()
+			report[i] = shardHealth(ctx, dial, shard.ShardID, shard.endpoint(node))
+		}(i, shard)
+	}
+	wg.Wait()
+	return report, nil
+}
+
+func shardHealth(ctx context.Context, dial rpc.Dialer, shardID int, endpoint string) ShardHealth {
+	health := ShardHealth{ShardID: shardID, Endpoint: endpoint}
+	if endpoint == "" {
+		health.Error = "shard advertises no endpoint for this transport"
+		return health
+	}
+	messenger := dial(endpoint)
+	defer closeMessenger(messenger)
+	none := []interface{}{}
+	replies, err := rpc.SendBatchContext(ctx, messenger, []rpc.Call{
+		{Method: rpc.Method.BlockNumber, Params: none},
+		{Method: rpc.Method.PeerCount, Params: none},
+		{Method: rpc.Method.Syncing, Params: none},
+		{Method: rpc.Method.GetLatestBlockHeader, Params: none},
+	})
+	if err == nil {
+		for _, reply := range replies {
+			if err = reply.Error; err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	var height, peers hexutil.Uint64
+	var syncing json.RawMessage
+	header := struct {
+		UnixTime int64 `json:"unixtime"`
+	}{}
+	for i, into := range []interface{}{&height, &peers, &syncing, &header} {
+		asJSON, _ := json.Marshal(replies[i].Reply["result"])
+		if err := json.Unmarshal(asJSON, into); err != nil {
+			health.Error = errors.Wrap(err, "unexpected reply").Error()
+			return health
+		}
+	}
+	health.Height, health.Peers = uint64(height), uint64(peers)
+	if header.UnixTime > 0 {
+		health.HeaderTime = time.Unix(header.UnixTime, 0)
+	}
+	// Syncing is false once caught up, true or a progress object while catching up
+	health.Syncing = string(syncing) == "true"
+	progress := struct {
+		CurrentBlock hexutil.Uint64 `json:"currentBlock"`
+		HighestBlock hexutil.Uint64 `json:"highestBlock"`
+	}{}
+	if json.Unmarshal(syncing, &progress) == nil {
+		health.Syncing = true
+		if progress.HighestBlock > progress.CurrentBlock {
+			health.BlocksBehind = uint64(progress.HighestBlock - progress.CurrentBlock)
+		}
+	}
+	return health
+}
@@ -0,0 +1,36 @@
+package sharding_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/go-sdk/pkg/sharding"
+)
+
+func TestHealthAcrossShards(t *testing.T) {
+	network := mocknode.NewNetwork(2, common.Chain.TestNet)
+	defer network.Close()
+	network.Shards[1].Handle(rpc.Method.Syncing, func([]json.RawMessage) (interface{}, error) {
+		return map[string]string{"currentBlock": "0x10", "highestBlock": "0x42"}, nil
+	})
+
+	report, err := sharding.Health(context.Background(), network.Shards[0].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 {
+		t.Fatalf("expected both shards, got %+v", report)
+	}
+	for i, shard := range report {
+		if shard.Error != "" || shard.ShardID != i || shard.HeaderTime.IsZero() {
+			t.Errorf("unexpected health of shard %d: %+v", i, shard)
+		}
+	}
+	if report[0].Syncing || !report[1].Syncing || report[1].BlocksBehind != 0x32 {
+		t.Errorf("unexpected sync state %+v", report)
+	}
+}
@@ -0,0 +1,122 @@
+package sharding
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+// BeaconShard is the shard staking transactions are sent to
+const BeaconShard uint32 = 0
+
+// ErrUnknownShard is returned for a shard the network does not have
+var ErrUnknownShard = errors.New("shard is not part of the network")
+
+// Router hands out the messenger of each shard of the network its root nodes
+// belong to, the messengers are created on first use and shared afterwards
+type Router struct {
+	dial       rpc.Dialer
+	endpoints  map[uint32][]string
+	lock       sync.Mutex
+	messengers map[uint32]rpc.T
+}
+
+// NewRouter discovers the shards of the network node belongs to, node may
+// list several comma separated root nodes
+func NewRouter(ctx context.Context, node string) (*Router, error) {
+	return NewRouterUsing(ctx, dialEndpoints, node)
+}
+
+// NewRouterUsing is NewRouter reaching nodes through the messengers of dial.
+// The endpoints of a shard are handed to dial comma separated, there are several
+// when the root nodes advertise different ones. Every root node is asked and
+// the router is only refused when none of them answers
+func NewRouterUsing(ctx context.Context, dial rpc.Dialer, node string) (*Router, error) {
+	R := &Router{dial: dial, endpoints: make(map[uint32][]string), messengers: make(map[uint32]rpc.T)}
+	seen := map[string]bool{}
+	err := errors.New("no node given")
+	answered := false
+	for _, n := range rpc.SplitNodes(node) {
+		var routes []RPCRoutes
+		if routes, err = StructureUsing(ctx, dial, n); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		answered = true
+		for _, route := range routes {
+			// Stay on the transport the root node was reached with
+			endpoint := route.endpoint(n)
+			id := uint32(route.ShardID)
+			if endpoint != "" && !seen[endpoint] {
+				seen[endpoint] = true
+				R.endpoints[id] = append(R.endpoints[id], endpoint)
+			}
+		}
+	}
+	if !answered {
+		return nil, err
+	}
+	return R, nil
+}
+
+// ShardCount is how many shards the network has
+func (R *Router) ShardCount() uint32 {
+	return uint32(len(R.endpoints))
+}
+
+// Shards lists the ids of the shards of the network in order
+func (R *Router) Shards() []uint32 {
+	ids := make([]uint32, 0, len(R.endpoints))
+	for id := range R.endpoints {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Endpoints are the endpoints serving shard id, empty for an unknown shard
+func (R *Router) Endpoints(id uint32) []string {
+	return append([]string{}, R.endpoints[id]...)
+}
+
+// ForShard returns the messenger of shard id, the router owns it and closes it in Close
+func (R *Router) ForShard(id uint32) (rpc.T, error) {
+	endpoints, ok := R.endpoints[id]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownShard, "shard %d, the network has %d shards", id, len(R.endpoints))
+	}
+	R.lock.Lock()
+	defer R.lock.Unlock()
+	messenger, ok := R.messengers[id]
+	if !ok {
+		messenger = R.dial(strings.Join(endpoints, ","))
+		R.messengers[id] = messenger
+	}
+	return messenger, nil
+}
+
+// Close closes the messengers handed out that hold connections
+func (R *Router) Close() error {
+	R.lock.Lock()
+	defer R.lock.Unlock()
+	for id, messenger := range R.messengers {
+		closeMessenger(messenger)
+		delete(R.messengers, id)
+	}
+	return nil
+}
+
+// dialEndpoints pools the endpoints of a shard when there are several
+func dialEndpoints(node string) rpc.T {
+	if endpoints := rpc.SplitNodes(node); len(endpoints) > 1 {
+		return rpc.NewPoolHandler(endpoints)
+	}
+	return rpc.NewHandler(node)
+}
@@ -0,0 +1,55 @@
+package sharding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestRouterRoutesByShard(t *testing.T) {
+	replayer, err := rpc.LoadReplayer("testdata/router.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewRouterUsing(context.Background(), replayer.Handler, "https://api.s0.b.hmny.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer router.Close()
+	if router.ShardCount() != 2 {
+		t.Fatalf("expected 2 shards, got %v", router.Shards())
+	}
+	messenger, err := router.ForShard(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := router.ForShard(1); again != messenger {
+		t.Error("the messenger of a shard was not reused")
+	}
+	reply, err := messenger.SendRPC(rpc.Method.BlockNumber, []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["result"] != "0x2a" {
+		t.Errorf("call did not reach shard 1, got %v", reply)
+	}
+	if _, err := router.ForShard(4); errors.Cause(err) != ErrUnknownShard {
+		t.Errorf("expected ErrUnknownShard for shard 4, got %v", err)
+	}
+	if !replayer.Exhausted() {
+		t.Error("not every recorded call was made")
+	}
+}
+
+func TestRouterFailsWithoutStructure(t *testing.T) {
+	replayer, err := rpc.LoadReplayer("testdata/router.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRouterUsing(context.Background(), replayer.Handler, "https://api.s9.b.hmny.io"); err == nil {
+		t.Fatal("expected the router to fail when no root node answers")
+	}
+}
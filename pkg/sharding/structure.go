@@ -1,13 +1,17 @@
 package sharding
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
-	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
 
-	"github.com/harmony-one/go-sdk/pkg/rpc"
 	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
 )
 
 // RPCRoutes reflects the RPC endpoints of the target network across shards
@@ -17,49 +21,168 @@ type RPCRoutes struct {
 	WS      string `json:"ws"`
 }
 
+// endpoint is the endpoint of route on the transport node was reached with, the
+// first node when several are listed, "" when the shard advertises none
+func (route RPCRoutes) endpoint(node string) string {
+	if rpc.IsWebSocket(node) {
+		return route.WS
+	}
+	return route.HTTP
+}
+
 // Structure produces a slice of RPCRoutes for the network across shards
 func Structure(node string) ([]RPCRoutes, error) {
-	type r struct {
-		Result []RPCRoutes `json:"result"`
+	return StructureContext(context.Background(), node)
+}
+
+// StructureContext is Structure bounded by ctx, node may list several comma
+// separated endpoints and the first one to answer is used
+func StructureContext(ctx context.Context, node string) ([]RPCRoutes, error) {
+	return StructureUsing(ctx, rpc.NewHandler, node)
+}
+
+// StructureUsing is StructureContext reaching nodes through the messengers of dial,
+// e.g. an rpc.Replayer in tests
+func StructureUsing(ctx context.Context, dial rpc.Dialer, node string) ([]RPCRoutes, error) {
+	err := fmt.Errorf("no node given")
+	for _, n := range rpc.SplitNodes(node) {
+		var routes []RPCRoutes
+		if routes, err = structureOf(ctx, dial(n)); err == nil {
+			return routes, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
 	}
-	p, e := rpc.RawRequest(rpc.Method.GetShardingStructure, node, []interface{}{})
-	if e != nil {
-		return nil, e
+	return nil, err
+}
+
+func structureOf(ctx context.Context, messenger rpc.T) ([]RPCRoutes, error) {
+	defer closeMessenger(messenger)
+	reply, err := rpc.SendRPCContext(ctx, messenger, rpc.Method.GetShardingStructure, []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	asJSON, _ := json.Marshal(reply["result"])
+	result := []RPCRoutes{}
+	json.Unmarshal(asJSON, &result)
+	return result, nil
+}
+
+func closeMessenger(messenger rpc.T) {
+	if closer, ok := messenger.(io.Closer); ok {
+		closer.Close()
 	}
-	result := r{}
-	json.Unmarshal(p, &result)
-	return result.Result, nil
 }
 
+// CheckAllShards reports the balance of oneAddr on every shard
 func CheckAllShards(node, oneAddr string, noPretty bool) (string, error) {
-	var out bytes.Buffer
-	out.WriteString("[")
-	params := []interface{}{oneAddr, "latest"}
-	s, err := Structure(node)
+	return CheckAllShardsContext(context.Background(), node, oneAddr, noPretty)
+}
+
+// CheckAllShardsContext is CheckAllShards bounded by ctx
+func CheckAllShardsContext(ctx context.Context, node, oneAddr string, noPretty bool) (string, error) {
+	return CheckAllShardsUsing(ctx, rpc.NewHandler, node, oneAddr, noPretty)
+}
+
+// CheckAllShardsUsing is CheckAllShardsContext reaching nodes through the messengers of dial
+func CheckAllShardsUsing(ctx context.Context, dial rpc.Dialer, node, oneAddr string, noPretty bool) (string, error) {
+	balances, err := balancesAcrossShards(ctx, dial, node, []string{oneAddr})
+	if err != nil {
+		return "", err
+	}
+	out, _ := json.Marshal(balances[oneAddr])
+	if noPretty {
+		return string(out), nil
+	}
+	return common.JSONPrettyFormat(string(out)), nil
+}
+
+// CheckAllShardsForAddresses reports the balances of many addresses keyed by address,
+// each shard is asked for all of them in a single batch
+func CheckAllShardsForAddresses(node string, oneAddrs []string, noPretty bool) (string, error) {
+	return CheckAllShardsForAddressesContext(context.Background(), node, oneAddrs, noPretty)
+}
+
+// CheckAllShardsForAddressesContext is CheckAllShardsForAddresses bounded by ctx
+func CheckAllShardsForAddressesContext(
+	ctx context.Context, node string, oneAddrs []string, noPretty bool,
+) (string, error) {
+	return CheckAllShardsForAddressesUsing(ctx, rpc.NewHandler, node, oneAddrs, noPretty)
+}
+
+// CheckAllShardsForAddressesUsing is CheckAllShardsForAddressesContext reaching nodes
+// through the messengers of dial
+func CheckAllShardsForAddressesUsing(
+	ctx context.Context, dial rpc.Dialer, node string, oneAddrs []string, noPretty bool,
+) (string, error) {
+	balances, err := balancesAcrossShards(ctx, dial, node, oneAddrs)
 	if err != nil {
 		return "", err
 	}
-	for i, shard := range s {
-		balanceRPCReply, err := rpc.Request(rpc.Method.GetBalance, shard.HTTP, params)
+	out, _ := json.Marshal(balances)
+	if noPretty {
+		return string(out), nil
+	}
+	return common.JSONPrettyFormat(string(out)), nil
+}
+
+type shardBalance struct {
+	Shard  int         `json:"shard"`
+	Amount json.Number `json:"amount"`
+}
+
+func balancesAcrossShards(
+	ctx context.Context, dial rpc.Dialer, node string, oneAddrs []string,
+) (map[string][]shardBalance, error) {
+	s, err := StructureUsing(ctx, dial, node)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]rpc.Call, len(oneAddrs))
+	for i, oneAddr := range oneAddrs {
+		calls[i] = rpc.Call{Method: rpc.Method.GetBalance, Params: []interface{}{oneAddr, "latest"}}
+	}
+	balances := make(map[string][]shardBalance, len(oneAddrs))
+	for _, oneAddr := range oneAddrs {
+		balances[oneAddr] = []shardBalance{}
+	}
+	// A shard that fails is left out, the messengers report why through their interceptors
+	var failure error
+	answered := false
+	for _, shard := range s {
+		endpoint := shard.endpoint(node)
+		if endpoint == "" {
+			failure = errors.Errorf("shard %d advertises no endpoint for %s", shard.ShardID, node)
+			continue
+		}
+		messenger := dial(endpoint)
+		replies, err := rpc.SendBatchContext(ctx, messenger, calls)
+		closeMessenger(messenger)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil {
-			if common.DebugRPC {
-				fmt.Printf("NOTE: Route %s failed.", shard.HTTP)
-			}
+			failure = errors.Wrapf(err, "shard %d at %s", shard.ShardID, endpoint)
 			continue
 		}
-		balance, _ := balanceRPCReply["result"].(string)
-		bln, _ := big.NewInt(0).SetString(balance[2:], 16)
-		out.WriteString(fmt.Sprintf(`{"shard":%d, "amount":%s}`,
-			shard.ShardID,
-			common.ConvertBalanceIntoReadableFormat(bln),
-		))
-		if i != len(s)-1 {
-			out.WriteString(",")
+		answered = true
+		for i, reply := range replies {
+			if reply.Error != nil {
+				continue
+			}
+			balance, _ := reply.Reply["result"].(string)
+			bln, ok := big.NewInt(0).SetString(strings.TrimPrefix(balance, "0x"), 16)
+			if !ok {
+				continue
+			}
+			balances[oneAddrs[i]] = append(balances[oneAddrs[i]], shardBalance{
+				shard.ShardID, json.Number(common.ConvertBalanceIntoReadableFormat(bln)),
+			})
 		}
 	}
-	out.WriteString("]")
-	if noPretty {
-		return out.String(), nil
+	if !answered && failure != nil {
+		return nil, errors.Wrap(failure, "no shard answered")
 	}
-	return common.JSONPrettyFormat(out.String()), nil
+	return balances, nil
 }
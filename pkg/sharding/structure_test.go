@@ -0,0 +1,92 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+)
+
+func TestCheckAllShardsSkipsFailingShards(t *testing.T) {
+	replayer, err := rpc.LoadReplayer("testdata/balances.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := CheckAllShardsUsing(
+		context.Background(), replayer.Handler,
+		"https://api.s0.b.hmny.io", "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy", true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances := []shardBalance{}
+	if err := json.Unmarshal([]byte(out), &balances); err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 1 || balances[0].Shard != 0 {
+		t.Fatalf("expected only shard 0 to report, got %s", out)
+	}
+	if amount, _ := balances[0].Amount.Float64(); amount != 2 {
+		t.Errorf("expected a balance of 2, got %s", balances[0].Amount)
+	}
+	if !replayer.Exhausted() {
+		t.Error("not every recorded call was made")
+	}
+}
+
+// answering is a node that answers every method with what answer gives for it
+type answering func(method string) interface{}
+
+func (answer answering) SendRPC(method string, params []interface{}) (rpc.Reply, error) {
+	return rpc.Reply{"result": answer(method)}, nil
+}
+
+func TestShardCommandsStayOnTheRootTransport(t *testing.T) {
+	var lock sync.Mutex
+	dialed := []string{}
+	node := answering(func(method string) interface{} {
+		switch method {
+		case rpc.Method.GetShardingStructure:
+			return []interface{}{
+				map[string]interface{}{"shardID": 0, "http": "http://s0", "ws": "ws://s0"},
+				map[string]interface{}{"shardID": 1, "http": "http://s1", "ws": "ws://s1"},
+			}
+		case rpc.Method.Syncing:
+			return false
+		case rpc.Method.GetLatestBlockHeader:
+			return map[string]interface{}{}
+		}
+		return "0x1"
+	})
+	dial := func(n string) rpc.T {
+		lock.Lock()
+		defer lock.Unlock()
+		dialed = append(dialed, n)
+		return node
+	}
+	if _, err := CheckAllShardsUsing(context.Background(), dial, "ws://root", "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy", true); err != nil {
+		t.Fatal(err)
+	}
+	health, err := HealthUsing(context.Background(), dial, "ws://root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, shard := range health {
+		if shard.Error != "" {
+			t.Errorf("shard %d: %s", shard.ShardID, shard.Error)
+		}
+	}
+	sort.Strings(dialed)
+	expected := []string{"ws://root", "ws://root", "ws://s0", "ws://s0", "ws://s1", "ws://s1"}
+	if len(dialed) != len(expected) {
+		t.Fatalf("dialed %v", dialed)
+	}
+	for i := range expected {
+		if dialed[i] != expected[i] {
+			t.Fatalf("expected %v to be dialed, got %v", expected, dialed)
+		}
+	}
+}
@@ -90,6 +90,12 @@ func DefaultLocation() string {
 	return path.Join(uDir, c.DefaultConfigDirName, c.DefaultConfigAccountAliasesDirName)
 }
 
+// RPCCacheLocation is where replies of the node that cannot change are kept between runs
+func RPCCacheLocation() string {
+	uDir, _ := homedir.Dir()
+	return path.Join(uDir, c.DefaultConfigDirName, c.DefaultConfigRPCCacheDirName)
+}
+
 func UnlockedKeystore(from, unlockP string) (*keystore.KeyStore, *accounts.Account, error) {
 	sender := address.Parse(from)
 	ks := FromAddress(from)
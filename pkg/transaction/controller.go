@@ -1,7 +1,7 @@
 package transaction
 
 import (
-	"encoding/base64"
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -18,6 +18,7 @@ import (
 	"github.com/harmony-one/harmony/accounts/keystore"
 	"github.com/harmony-one/harmony/common/denominations"
 	"github.com/harmony-one/harmony/core"
+	pkgerrors "github.com/pkg/errors"
 )
 
 type p []interface{}
@@ -29,6 +30,8 @@ type transactionForRPC struct {
 	signature   *string
 	receiptHash *string
 	receipt     rpc.Reply
+	// sent is set once the signed transaction is handed to the node, whatever came back
+	sent bool
 }
 
 type sender struct {
@@ -39,17 +42,26 @@ type sender struct {
 // Controller drives the transaction signing process
 type Controller struct {
 	failure           error
+	ctx               context.Context
 	messenger         rpc.T
 	sender            sender
 	transactionForRPC transactionForRPC
 	chain             common.ChainID
+	nonces            *NonceManager
 	Behavior          behavior
 }
 
+// DefaultGasMarginPercent is added on top of the gas the node estimates, as state may change before the transaction lands
+const DefaultGasMarginPercent = 20
+
 type behavior struct {
 	DryRun               bool
 	SigningImpl          SignerImpl
 	ConfirmationWaitTime uint32
+	// GasLimit overrides the estimated gas when non-zero
+	GasLimit uint64
+	// GasMarginPercent is added to the gas estimated by the node
+	GasMarginPercent uint64
 }
 
 // NewController initializes a Controller, caller can control behavior via options
@@ -63,6 +75,7 @@ func NewController(
 	txParams := make(map[string]interface{})
 	ctrlr := &Controller{
 		failure:   nil,
+		ctx:       context.Background(),
 		messenger: handler,
 		sender: sender{
 			ks:      senderKs,
@@ -74,8 +87,12 @@ func NewController(
 			receiptHash: nil,
 			receipt:     nil,
 		},
-		chain:    chain,
-		Behavior: behavior{false, Software, 0},
+		chain: chain,
+		Behavior: behavior{
+			DryRun:           false,
+			SigningImpl:      Software,
+			GasMarginPercent: DefaultGasMarginPercent,
+		},
 	}
 	for _, option := range options {
 		option(ctrlr)
@@ -83,53 +100,119 @@ func NewController(
 	return ctrlr
 }
 
-func (C *Controller) verifyBalance(amount float64) {
+// WithContext bounds every RPC call the Controller makes by ctx, meant as an option to NewController
+func WithContext(ctx context.Context) func(*Controller) {
+	return func(C *Controller) {
+		C.ctx = ctx
+	}
+}
+
+// WithNonceManager takes nonces from nonces rather than from the node, so Controllers
+// sharing it can send for the same sender at once, meant as an option to NewController
+func WithNonceManager(nonces *NonceManager) func(*Controller) {
+	return func(C *Controller) {
+		C.nonces = nonces
+	}
+}
+
+// verifyChain refuses to sign for C.chain when the node is on another chain,
+// nodes too old to describe themselves are taken at their word
+func (C *Controller) verifyChain() {
 	if C.failure != nil {
 		return
 	}
-	balanceRPCReply, err := C.messenger.SendRPC(
-		rpc.Method.GetBalance,
-		p{address.ToBech32(C.sender.account.Address), "latest"},
-	)
+	metadata, err := rpc.NewClient(C.messenger).GetNodeMetadata(C.ctx)
 	if err != nil {
+		if rpc.IsMethodNotFound(err) {
+			return
+		}
 		C.failure = err
 		return
 	}
-	currentBalance, _ := balanceRPCReply["result"].(string)
-	balance, _ := big.NewInt(0).SetString(currentBalance[2:], 16)
-	balance = common.NormalizeAmount(balance)
-	transfer := big.NewInt(int64(amount * denominations.Nano))
-
-	tns := (float64(transfer.Uint64()) / denominations.Nano)
-	bln := (float64(balance.Uint64()) / denominations.Nano)
-
-	if tns > bln {
+	nodeChainID := metadata.ChainIDValue()
+	switch {
+	case nodeChainID != nil && nodeChainID.Cmp(C.chain.Value) != 0,
+		nodeChainID == nil && metadata.NetworkType != "" && metadata.NetworkType != C.chain.Name:
 		C.failure = fmt.Errorf(
-			"current balance of %.6f is not enough for the requested transfer %.6f", bln, tns,
+			"node is on network %s with chain-id %s but the transaction is for %s with chain-id %s, check --chain-id and --node",
+			metadata.NetworkType, metadata.ChainID, C.chain.Name, C.chain.Value.String(),
 		)
 	}
 }
 
-func (C *Controller) setNextNonce() {
+// fetchBalanceAndNonce asks for the sender's balance and next nonce in one batch
+func (C *Controller) fetchBalanceAndNonce() {
 	if C.failure != nil {
 		return
 	}
-	transactionCountRPCReply, err :=
-		C.messenger.SendRPC(rpc.Method.GetTransactionCount, p{C.sender.account.Address.Hex(), "latest"})
+	calls := []rpc.Call{
+		{Method: rpc.Method.GetBalance, Params: p{address.ToBech32(C.sender.account.Address), "latest"}},
+	}
+	if C.nonces == nil {
+		calls = append(calls, rpc.Call{Method: rpc.Method.GetTransactionCount, Params: p{C.sender.account.Address.Hex(), "latest"}})
+	}
+	replies, err := rpc.SendBatchContext(C.ctx, C.messenger, calls)
 	if err != nil {
 		C.failure = err
 		return
 	}
-	transactionCount, _ := transactionCountRPCReply["result"].(string)
-	nonce, _ := big.NewInt(0).SetString(transactionCount[2:], 16)
-	C.transactionForRPC.params["nonce"] = nonce.Uint64()
+	for _, reply := range replies {
+		if reply.Error != nil {
+			C.failure = reply.Error
+			return
+		}
+	}
+	currentBalance, _ := replies[0].Reply["result"].(string)
+	balance, err := hexutil.DecodeBig(currentBalance)
+	if err != nil {
+		C.failure = pkgerrors.Wrapf(err, "unexpected balance %q", currentBalance)
+		return
+	}
+	C.transactionForRPC.params["sender-balance"] = balance
+	if C.nonces != nil {
+		nonce, err := C.nonces.Next(
+			C.ctx, C.messenger, address.ToBech32(C.sender.account.Address), C.transactionForRPC.params["from-shard"].(uint32),
+		)
+		if err != nil {
+			C.failure = err
+			return
+		}
+		C.transactionForRPC.params["nonce"] = nonce
+		return
+	}
+	transactionCount, _ := replies[1].Reply["result"].(string)
+	nonce, err := hexutil.DecodeUint64(transactionCount)
+	if err != nil {
+		C.failure = pkgerrors.Wrapf(err, "unexpected transaction count %q", transactionCount)
+		return
+	}
+	C.transactionForRPC.params["nonce"] = nonce
+}
+
+// verifyBalance checks the sender can pay for amount and the gas at once
+func (C *Controller) verifyBalance(amount *big.Int) {
+	if C.failure != nil {
+		return
+	}
+	balance := C.transactionForRPC.params["sender-balance"].(*big.Int)
+	fee := new(big.Int).SetUint64(C.transactionForRPC.params["gas"].(uint64))
+	fee.Mul(fee, C.transactionForRPC.params["gas-price"].(*big.Int))
+	if balance.Cmp(new(big.Int).Add(amount, fee)) < 0 {
+		C.failure = fmt.Errorf(
+			"current balance of %s is not enough for the requested transfer %s and a fee of up to %s",
+			common.FormatAmount(balance), common.FormatAmount(amount), common.FormatAmount(fee),
+		)
+	}
 }
 
 func (C *Controller) sendSignedTx() {
 	if C.failure != nil || C.Behavior.DryRun {
 		return
 	}
-	reply, err := C.messenger.SendRPC(rpc.Method.SendRawTransaction, p{C.transactionForRPC.signature})
+	C.transactionForRPC.sent = true
+	reply, err := rpc.SendRPCContext(
+		C.ctx, C.messenger, rpc.Method.SendRawTransaction, p{C.transactionForRPC.signature},
+	)
 	if err != nil {
 		C.failure = err
 		return
@@ -138,52 +221,115 @@ func (C *Controller) sendSignedTx() {
 	C.transactionForRPC.receiptHash = &r
 }
 
-func (C *Controller) setIntrinsicGas(rawInput string) {
+// settleNonce tells the nonce manager whether the nonce it handed out was sent,
+// a dry run or a failure before sending gives it back
+func (C *Controller) settleNonce() {
+	nonce, reserved := C.transactionForRPC.params["nonce"].(uint64)
+	if C.nonces == nil || !reserved {
+		return
+	}
+	if C.Behavior.DryRun || !C.transactionForRPC.sent {
+		C.releaseNonce()
+		return
+	}
+	C.nonces.Done(address.ToBech32(C.sender.account.Address), C.transactionForRPC.params["from-shard"].(uint32), nonce, C.failure)
+}
+
+// releaseNonce gives back the nonce the nonce manager handed out, if any
+func (C *Controller) releaseNonce() {
+	nonce, reserved := C.transactionForRPC.params["nonce"].(uint64)
+	if C.nonces == nil || !reserved {
+		return
+	}
+	C.nonces.Release(address.ToBech32(C.sender.account.Address), C.transactionForRPC.params["from-shard"].(uint32), nonce)
+}
+
+// setGas uses Behavior.GasLimit when given. Otherwise a transaction carrying data
+// within one shard is estimated by the node with Behavior.GasMarginPercent on top,
+// anything else gets its intrinsic gas. A cross-shard receiver lives on a shard
+// the messenger does not reach, so it is never estimated
+func (C *Controller) setGas(data []byte) {
 	if C.failure != nil {
 		return
 	}
-	inputData, _ := base64.StdEncoding.DecodeString(rawInput)
-	// NOTE Need to add more intrisicGas
-	// in order to include more data in inputData
-	gas, _ := core.IntrinsicGas(inputData, false, true)
+	if C.Behavior.GasLimit > 0 {
+		C.transactionForRPC.params["gas"] = C.Behavior.GasLimit
+		return
+	}
+	gas, err := core.IntrinsicGas(data, false, true)
+	if err != nil {
+		C.failure = err
+		return
+	}
+	crossShard := C.transactionForRPC.params["from-shard"].(uint32) != C.transactionForRPC.params["to-shard"].(uint32)
+	if len(data) == 0 || crossShard {
+		C.transactionForRPC.params["gas"] = gas
+		return
+	}
+	receiver := C.transactionForRPC.params["receiver"].(address.T)
+	estimate, err := rpc.NewClient(C.messenger).EstimateGas(C.ctx, rpc.CallArgs{
+		From:  C.sender.account.Address.Hex(),
+		To:    receiver.Hex(),
+		Value: (*hexutil.Big)(C.transactionForRPC.params["transfer-amount"].(*big.Int)),
+		Data:  data,
+	})
+	if err != nil {
+		C.failure = pkgerrors.Wrap(err, "could not estimate gas, set --gas-limit")
+		return
+	}
+	estimate += estimate * C.Behavior.GasMarginPercent / 100
+	if estimate > gas {
+		gas = estimate
+	}
 	C.transactionForRPC.params["gas"] = gas
 }
 
-func (C *Controller) setGasPrice() {
+// setGasPrice uses gasPrice, in nano, when given and otherwise the price the node suggests
+func (C *Controller) setGasPrice(gasPrice int64) {
 	if C.failure != nil {
 		return
 	}
-	C.transactionForRPC.params["gas-price"] = nil
+	if gasPrice > 0 {
+		price := big.NewInt(gasPrice)
+		C.transactionForRPC.params["gas-price"] = price.Mul(price, big.NewInt(denominations.Nano))
+		return
+	}
+	price, err := rpc.NewGasPriceOracle(C.messenger).SuggestGasPrice(C.ctx)
+	if err != nil {
+		C.failure = pkgerrors.Wrap(err, "could not get a gas price, set --gas-price")
+		return
+	}
+	C.transactionForRPC.params["gas-price"] = price
 }
 
-func (C *Controller) setAmount(amount float64) {
-	amountBigInt := big.NewInt(int64(amount * denominations.Nano))
-	amt := amountBigInt.Mul(amountBigInt, big.NewInt(denominations.Nano))
-	C.transactionForRPC.params["transfer-amount"] = amt
+func (C *Controller) setAmount(amount *big.Int) {
+	if C.failure != nil {
+		return
+	}
+	if amount.Sign() < 0 {
+		C.failure = fmt.Errorf("cannot transfer a negative amount %s", common.FormatAmount(amount))
+		return
+	}
+	C.transactionForRPC.params["transfer-amount"] = new(big.Int).Set(amount)
 }
 
 func (C *Controller) setReceiver(receiver string) {
 	C.transactionForRPC.params["receiver"] = address.Parse(receiver)
 }
 
-func (C *Controller) setNewTransactionWithDataAndGas(i string, amount float64, gasPrice int64) {
+func (C *Controller) setNewTransactionWithDataAndGas(data []byte) {
 	if C.failure != nil {
 		return
 	}
-	amountBigInt := big.NewInt(int64(amount * denominations.Nano))
-	amt := amountBigInt.Mul(amountBigInt, big.NewInt(denominations.Nano))
-	gPrice := big.NewInt(gasPrice)
-	gPrice = gPrice.Mul(gPrice, big.NewInt(denominations.Nano))
-
 	tx := NewTransaction(
 		C.transactionForRPC.params["nonce"].(uint64),
 		C.transactionForRPC.params["gas"].(uint64),
 		C.transactionForRPC.params["receiver"].(address.T),
 		C.transactionForRPC.params["from-shard"].(uint32),
 		C.transactionForRPC.params["to-shard"].(uint32),
-		amt,
-		gPrice,
-		[]byte(i),
+		C.transactionForRPC.params["transfer-amount"].(*big.Int),
+		C.transactionForRPC.params["gas-price"].(*big.Int),
+		data,
 	)
 	C.transactionForRPC.transaction = tx
 }
@@ -267,41 +413,143 @@ func (C *Controller) txConfirmation() {
 			if start < 0 {
 				return
 			}
-			r, _ := C.messenger.SendRPC(rpc.Method.GetTransactionReceipt, p{receipt})
+			r, _ := rpc.SendRPCContext(C.ctx, C.messenger, rpc.Method.GetTransactionReceipt, p{receipt})
 			if r["result"] != nil {
 				C.transactionForRPC.receipt = r
 				return
 			}
-			time.Sleep(time.Second * 2)
+			select {
+			case <-time.After(time.Second * 2):
+			case <-C.ctx.Done():
+				C.failure = C.ctx.Err()
+				return
+			}
 			start = start - 2
 		}
 	}
 }
 
-// ExecuteTransaction is the single entrypoint to execute a transaction.
+// ExecuteTransaction is the single entrypoint to execute a transaction, data is the
+// payload as sent, amount is in atto and gPrice in nano, 0 takes the gas price suggested by the node.
 // Each step in transaction creation, execution probably includes a mutation
 // Each becomes a no-op if failure occured in any previous step
 func (C *Controller) ExecuteTransaction(
-	to, inputData string,
-	amount float64, gPrice int64,
+	to string, data []byte,
+	amount *big.Int, gPrice int64,
 	fromShard, toShard int,
 ) error {
 	// WARNING Order of execution matters
+	C.build(to, data, amount, gPrice, fromShard, toShard)
+	C.sign()
+	C.sendSignedTx()
+	C.settleNonce()
+	C.txConfirmation()
+	return C.failure
+}
+
+// BuildTransaction is the first step of ExecuteTransaction, the nonce and gas are
+// taken from the node and nothing is signed, the result can be signed offline.
+// The nonce is a snapshot, one reserved with a nonce manager is given back at once
+// as nothing is sent, so it may be handed out again before the file is broadcast
+func (C *Controller) BuildTransaction(
+	to string, data []byte,
+	amount *big.Int, gPrice int64,
+	fromShard, toShard int,
+) (*UnsignedTransaction, error) {
+	C.build(to, data, amount, gPrice, fromShard, toShard)
+	C.releaseNonce()
+	if C.failure != nil {
+		return nil, C.failure
+	}
+	tx := C.transactionForRPC.transaction
+	return &UnsignedTransaction{
+		Version:   OfflineFormatVersion,
+		ChainID:   C.chain.Name,
+		From:      address.ToBech32(C.sender.account.Address),
+		To:        address.ToBech32(*tx.To()),
+		FromShard: tx.ShardID(),
+		ToShard:   tx.ToShardID(),
+		Nonce:     tx.Nonce(),
+		GasLimit:  tx.Gas(),
+		GasPrice:  tx.GasPrice().String(),
+		Amount:    tx.Value().String(),
+		Data:      tx.Data(),
+	}, nil
+}
+
+// SignTransaction signs unsigned with the key of the sender, it needs no node
+func (C *Controller) SignTransaction(unsigned *UnsignedTransaction) (*SignedTransaction, error) {
+	if C.failure != nil {
+		return nil, C.failure
+	}
+	if unsigned.ChainID != C.chain.Name {
+		return nil, fmt.Errorf("transaction was built for %s, not %s", unsigned.ChainID, C.chain.Name)
+	}
+	if from := address.ToBech32(C.sender.account.Address); unsigned.From != from {
+		return nil, fmt.Errorf("transaction was built for sender %s, not %s", unsigned.From, from)
+	}
+	C.transactionForRPC.transaction, C.failure = unsigned.Transaction()
+	C.sign()
+	if C.failure != nil {
+		return nil, C.failure
+	}
+	signed, err := DecodeRawTransaction(*C.transactionForRPC.signature)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTransaction{
+		Version:   OfflineFormatVersion,
+		ChainID:   C.chain.Name,
+		From:      unsigned.From,
+		FromShard: unsigned.FromShard,
+		Hash:      signed.Hash().Hex(),
+		Raw:       *C.transactionForRPC.signature,
+	}, nil
+}
+
+// SendRawTransaction broadcasts a transaction signed for the chain of the Controller,
+// then waits for its receipt as ExecuteTransaction does. One signed for another
+// chain is refused before anything is sent
+func (C *Controller) SendRawTransaction(raw string) error {
+	tx, err := DecodeRawTransaction(raw)
+	if err != nil {
+		return err
+	}
+	if tx.ChainID().Cmp(C.chain.Value) != 0 {
+		return fmt.Errorf(
+			"transaction was signed for chain-id %s, not %s with chain-id %s", tx.ChainID(), C.chain.Name, C.chain.Value,
+		)
+	}
+	C.verifyChain()
+	C.transactionForRPC.signature = &raw
+	C.sendSignedTx()
+	C.txConfirmation()
+	return C.failure
+}
+
+// build fills the transaction from the node, up to but excluding signing.
+// A gPrice of 0 takes the gas price suggested by the node
+func (C *Controller) build(
+	to string, data []byte,
+	amount *big.Int, gPrice int64,
+	fromShard, toShard int,
+) {
+	C.verifyChain()
 	C.setShardIDs(fromShard, toShard)
-	C.setIntrinsicGas(inputData)
 	C.setAmount(amount)
-	C.verifyBalance(amount)
 	C.setReceiver(to)
-	C.setGasPrice()
-	C.setNextNonce()
-	C.setNewTransactionWithDataAndGas(inputData, amount, gPrice)
+	C.fetchBalanceAndNonce()
+	C.setGas(data)
+	C.setGasPrice(gPrice)
+	C.verifyBalance(amount)
+	C.setNewTransactionWithDataAndGas(data)
+}
+
+func (C *Controller) sign() {
 	switch C.Behavior.SigningImpl {
 	case Software:
 		C.signAndPrepareTxEncodedForSending()
 	case Ledger:
 		C.hardwareSignAndPrepareTxEncodedForSending()
 	}
-	C.sendSignedTx()
-	C.txConfirmation()
-	return C.failure
 }
@@ -0,0 +1,227 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/harmony/accounts"
+	"github.com/harmony-one/harmony/accounts/keystore"
+	"github.com/harmony-one/harmony/common/denominations"
+)
+
+func TestControllerRefusesOtherChain(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	ctrlr := NewController(rpc.NewHTTPHandler(node.URL), nil, nil, common.Chain.MainNet)
+	err := ctrlr.ExecuteTransaction("", nil, big.NewInt(denominations.One), 1, 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "chain-id 2") {
+		t.Errorf("expected a chain-id mismatch, got %v", err)
+	}
+}
+
+func TestBuildTransactionRefusesMalformedBalance(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	from := "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+	account := accounts.Account{Address: address.Parse(from)}
+	for _, malformed := range []string{"", "0x", "0xzz"} {
+		balance := malformed
+		node.Handle(rpc.Method.GetBalance, func([]json.RawMessage) (interface{}, error) { return balance, nil })
+		_, err := NewController(rpc.NewHTTPHandler(node.URL), nil, &account, common.Chain.TestNet).
+			BuildTransaction(from, nil, big.NewInt(1), 1, 0, 0)
+		if err == nil || !strings.Contains(err.Error(), "unexpected balance") {
+			t.Errorf("balance %q: expected a decoding error, got %v", malformed, err)
+		}
+	}
+}
+
+func TestBuildTransactionForOfflineSigning(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	from := "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+	to := "one1q6gkzcap0uruuu8r6sldxuu47pd4ww9w9t7tg6"
+	node.SetBalance(from, new(big.Int).Mul(big.NewInt(10), big.NewInt(denominations.One)))
+	node.SetNonce(from, 7)
+	account := accounts.Account{Address: address.Parse(from)}
+	ctrlr := NewController(rpc.NewHTTPHandler(node.URL), nil, &account, common.Chain.TestNet)
+	unsigned, err := ctrlr.BuildTransaction(to, nil, big.NewInt(denominations.One), 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "unsigned.json")
+	if err := unsigned.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadUnsignedTransaction(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Nonce != 7 || loaded.Amount != "1000000000000000000" || loaded.ToShard != 1 ||
+		loaded.ChainID != "testnet" || loaded.From != from || loaded.To != to {
+		t.Errorf("unexpected unsigned transaction %+v", loaded)
+	}
+	loaded.Version = OfflineFormatVersion + 1
+	if _, err := loaded.Transaction(); err == nil {
+		t.Error("a file of a later version was accepted")
+	}
+}
+
+func TestBuildTransactionGivesBackManagedNonce(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	from := "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+	to := "one1q6gkzcap0uruuu8r6sldxuu47pd4ww9w9t7tg6"
+	node.SetBalance(from, new(big.Int).Mul(big.NewInt(10), big.NewInt(denominations.One)))
+	node.SetNonce(from, 7)
+	account := accounts.Account{Address: address.Parse(from)}
+	messenger, nonces := rpc.NewHTTPHandler(node.URL), NewNonceManager()
+	unsigned, err := NewController(messenger, nil, &account, common.Chain.TestNet, WithNonceManager(nonces)).
+		BuildTransaction(to, nil, big.NewInt(1), 1, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing was sent, the nonce in the file is still the next one
+	nonce, err := nonces.Next(context.Background(), messenger, from, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned.Nonce != 7 || nonce != 7 {
+		t.Errorf("built with nonce %d, the manager then handed out %d", unsigned.Nonce, nonce)
+	}
+}
+
+func TestBuildTransactionEstimatesGas(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	from := "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+	to := "one1q6gkzcap0uruuu8r6sldxuu47pd4ww9w9t7tg6"
+	node.SetBalance(from, new(big.Int).Mul(big.NewInt(10), big.NewInt(denominations.One)))
+	account := accounts.Account{Address: address.Parse(from)}
+	// The mock node estimates 21000 for any call and suggests a price of 1 atto
+	unsigned, err := NewController(rpc.NewHTTPHandler(node.URL), nil, &account, common.Chain.TestNet).
+		BuildTransaction(to, []byte("data"), big.NewInt(1), 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(unsigned.Data) != "data" {
+		t.Errorf("payload sent as %q", unsigned.Data)
+	}
+	if unsigned.GasLimit != 21000+21000*DefaultGasMarginPercent/100 || unsigned.GasPrice != "1" {
+		t.Errorf("expected the estimate with its margin and the suggested price, got %d at %s",
+			unsigned.GasLimit, unsigned.GasPrice,
+		)
+	}
+	overridden, err := NewController(rpc.NewHTTPHandler(node.URL), nil, &account, common.Chain.TestNet,
+		func(C *Controller) { C.Behavior.GasLimit = 50000 },
+	).BuildTransaction(to, []byte("data"), big.NewInt(1), 2, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overridden.GasLimit != 50000 || overridden.GasPrice != "2000000000" {
+		t.Errorf("overrides were not used, got %d at %s", overridden.GasLimit, overridden.GasPrice)
+	}
+}
+
+func TestBuildTransactionOnlyEstimatesCallsWithinTheShard(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	from := "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+	to := "one1q6gkzcap0uruuu8r6sldxuu47pd4ww9w9t7tg6"
+	node.SetBalance(from, new(big.Int).Mul(big.NewInt(10), big.NewInt(denominations.One)))
+	var asked int32
+	for _, method := range []string{rpc.Method.GetCode, rpc.Method.EstimateGas} {
+		h := node.Handler(method)
+		node.Handle(method, func(params []json.RawMessage) (interface{}, error) {
+			atomic.AddInt32(&asked, 1)
+			return h(params)
+		})
+	}
+	account := accounts.Account{Address: address.Parse(from)}
+	for _, c := range []struct {
+		name               string
+		data               []byte
+		fromShard, toShard int
+		gas                uint64
+	}{
+		{"plain transfer", nil, 0, 0, 21000},
+		{"cross-shard call", []byte("data"), 0, 1, 21000 + 4*68},
+	} {
+		unsigned, err := NewController(rpc.NewHTTPHandler(node.URL), nil, &account, common.Chain.TestNet).
+			BuildTransaction(to, c.data, big.NewInt(1), 0, c.fromShard, c.toShard)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if unsigned.GasLimit != c.gas {
+			t.Errorf("%s: expected the intrinsic gas %d, got %d", c.name, c.gas, unsigned.GasLimit)
+		}
+	}
+	if n := atomic.LoadInt32(&asked); n != 0 {
+		t.Errorf("the node was asked for code or an estimate %d times", n)
+	}
+}
+
+// unlockedAccount imports a fresh key into a keystore in a temporary directory
+// and unlocks it, cleanup removes the directory
+func unlockedAccount(t *testing.T) (*keystore.KeyStore, accounts.Account, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	account, err := ks.ImportECDSA(key, common.DefaultPassphrase)
+	if err == nil {
+		err = ks.Unlock(account, common.DefaultPassphrase)
+	}
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return ks, account, cleanup
+}
+
+func TestSendRawTransactionRefusesOtherChain(t *testing.T) {
+	node := mocknode.New(common.Chain.MainNet)
+	defer node.Close()
+	ks, account, cleanup := unlockedAccount(t)
+	defer cleanup()
+	from := address.ToBech32(account.Address)
+	node.SetBalance(from, big.NewInt(denominations.One))
+	unsigned := &UnsignedTransaction{
+		Version: OfflineFormatVersion, ChainID: common.Chain.TestNet.Name, From: from,
+		To: "one1q6gkzcap0uruuu8r6sldxuu47pd4ww9w9t7tg6", GasLimit: 21000, GasPrice: "1", Amount: "1",
+	}
+	signed, err := NewController(nil, ks, &account, common.Chain.TestNet).SignTransaction(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewController(rpc.NewHTTPHandler(node.URL), nil, nil, common.Chain.MainNet).SendRawTransaction(signed.Raw)
+	if err == nil || !strings.Contains(err.Error(), "signed for chain-id 2") {
+		t.Errorf("expected the testnet transaction to be refused, got %v", err)
+	}
+	if nonce := node.Nonce(from); nonce != 0 {
+		t.Error("the transaction reached the node")
+	}
+}
@@ -0,0 +1,189 @@
+package transaction
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// NonceManager hands out the nonces of senders locally, so transactions sent in
+// quick succession do not reuse the nonce the node reports while earlier ones
+// are still pending. The node is asked again after a gap or a nonce it refused.
+// It is safe for concurrent use
+type NonceManager struct {
+	lock     sync.Mutex
+	accounts map[nonceKey]*nonces
+}
+
+type nonceKey struct {
+	sender  address.T
+	shardID uint32
+}
+
+// nonces is what one sender on one shard has been handed out
+type nonces struct {
+	lock   sync.Mutex
+	synced bool
+	next   uint64
+	// floor is a nonce known to be used, resyncing never goes below it
+	floor uint64
+	// released are nonces below next given back unused, handed out first
+	released []uint64
+	// outstanding are nonces handed out and not yet reported on, a resync never hands them out again
+	outstanding map[uint64]struct{}
+}
+
+// NewNonceManager returns a manager that knows of no sender yet
+func NewNonceManager() *NonceManager {
+	return &NonceManager{accounts: make(map[nonceKey]*nonces)}
+}
+
+// Next reserves the next nonce of sender, as bech32 or hex, on shardID. messenger
+// must reach that shard, it is only used when the manager has to resync
+func (M *NonceManager) Next(ctx context.Context, messenger rpc.T, sender string, shardID uint32) (uint64, error) {
+	account := M.account(sender, shardID)
+	account.lock.Lock()
+	defer account.lock.Unlock()
+	if !account.synced {
+		count, err := pendingCount(ctx, messenger, address.Parse(sender))
+		if err != nil {
+			return 0, err
+		}
+		account.resync(count)
+	}
+	nonce := account.next
+	if len(account.released) > 0 {
+		nonce, account.released = account.released[0], account.released[1:]
+	} else {
+		account.next++
+	}
+	account.outstanding[nonce] = struct{}{}
+	return nonce, nil
+}
+
+// Done reports how sending the transaction with nonce went, err is nil once the
+// node accepted it. Any failure makes the next call resync, as a timeout or a
+// dropped connection leaves unknown whether the transaction landed. A nonce whose
+// transaction was never sent is given back with Release instead
+func (M *NonceManager) Done(sender string, shardID uint32, nonce uint64, err error) {
+	account := M.account(sender, shardID)
+	account.lock.Lock()
+	defer account.lock.Unlock()
+	delete(account.outstanding, nonce)
+	switch {
+	case err == nil:
+		if nonce+1 > account.floor {
+			account.floor = nonce + 1
+		}
+	case nonceRefused(err):
+		account.synced = false
+		if !nonceTooLow(err) {
+			// The node misses an earlier nonce, what was thought accepted may have been dropped
+			account.floor = 0
+		} else if nonce+1 > account.floor {
+			account.floor = nonce + 1
+		}
+	default:
+		// The transaction may have landed or not, the next call asks the node which
+		account.synced = false
+	}
+}
+
+// Release gives back a reserved nonce whose transaction was not sent
+func (M *NonceManager) Release(sender string, shardID uint32, nonce uint64) {
+	account := M.account(sender, shardID)
+	account.lock.Lock()
+	defer account.lock.Unlock()
+	delete(account.outstanding, nonce)
+	account.release(nonce)
+}
+
+// Reset forgets sender on shardID, the next nonce is asked of the node
+func (M *NonceManager) Reset(sender string, shardID uint32) {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	delete(M.accounts, nonceKey{address.Parse(sender), shardID})
+}
+
+func (M *NonceManager) account(sender string, shardID uint32) *nonces {
+	M.lock.Lock()
+	defer M.lock.Unlock()
+	key := nonceKey{address.Parse(sender), shardID}
+	account, ok := M.accounts[key]
+	if !ok {
+		account = &nonces{outstanding: make(map[uint64]struct{})}
+		M.accounts[key] = account
+	}
+	return account
+}
+
+// resync follows count, the nonce the node expects next, without going below the
+// floor or handing out again a nonce still outstanding. Nonces between count and
+// the highest outstanding one that nobody holds are released, caller must hold account.lock
+func (account *nonces) resync(count uint64) {
+	start := count
+	if account.floor > start {
+		start = account.floor
+	}
+	account.next, account.released, account.synced = start, nil, true
+	for nonce := range account.outstanding {
+		if nonce >= account.next {
+			account.next = nonce + 1
+		}
+	}
+	for nonce := start; nonce < account.next; nonce++ {
+		if _, held := account.outstanding[nonce]; !held {
+			account.released = append(account.released, nonce)
+		}
+	}
+}
+
+// release puts nonce back in line, caller must hold account.lock
+func (account *nonces) release(nonce uint64) {
+	if !account.synced || nonce >= account.next {
+		return
+	}
+	if nonce == account.next-1 {
+		account.next--
+		return
+	}
+	at := sort.Search(len(account.released), func(i int) bool { return account.released[i] >= nonce })
+	if at < len(account.released) && account.released[at] == nonce {
+		return
+	}
+	account.released = append(account.released, 0)
+	copy(account.released[at+1:], account.released[at:])
+	account.released[at] = nonce
+}
+
+// pendingCount is the nonce the node expects next from sender, counting its pool
+func pendingCount(ctx context.Context, messenger rpc.T, sender address.T) (uint64, error) {
+	reply, err := rpc.SendRPCContext(ctx, messenger, rpc.Method.GetTransactionCount, p{sender.Hex(), "pending"})
+	if err != nil {
+		return 0, err
+	}
+	count, _ := reply["result"].(string)
+	nonce, err := hexutil.DecodeUint64(count)
+	return nonce, pkgerrors.Wrap(err, "unexpected transaction count")
+}
+
+// nonceRefused reports errors of a node turning a transaction down for its nonce
+func nonceRefused(err error) bool {
+	rpcErr, ok := pkgerrors.Cause(err).(*rpc.RPCError)
+	if !ok {
+		return false
+	}
+	message := strings.ToLower(rpcErr.Message)
+	return strings.Contains(message, "nonce") || strings.Contains(message, "known transaction")
+}
+
+func nonceTooLow(err error) bool {
+	message := strings.ToLower(pkgerrors.Cause(err).Error())
+	return strings.Contains(message, "too low") || strings.Contains(message, "known transaction")
+}
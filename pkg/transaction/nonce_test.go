@@ -0,0 +1,203 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/harmony-one/go-sdk/pkg/common"
+	"github.com/harmony-one/go-sdk/pkg/mocknode"
+	"github.com/harmony-one/go-sdk/pkg/rpc"
+	"github.com/harmony-one/harmony/common/denominations"
+)
+
+const nonceSender = "one1pdv9lrdwl0rg5vglh4xtyrv3wjk3wsqket7zxy"
+
+// countedNonces makes node report the pending nonce held by count, asks counts the queries
+func countedNonces(node *mocknode.Node, count *uint64, asks *int32) {
+	node.Handle(rpc.Method.GetTransactionCount, func([]json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(asks, 1)
+		return hexutil.Uint64(atomic.LoadUint64(count)), nil
+	})
+}
+
+func TestNonceManagerConcurrentSenders(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	count, asks := uint64(5), int32(0)
+	countedNonces(node, &count, &asks)
+	messenger, nonces := rpc.NewHTTPHandler(node.URL), NewNonceManager()
+
+	const senders = 50
+	var wait sync.WaitGroup
+	handedOut := make([]uint64, senders)
+	for i := 0; i < senders; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			nonce, err := nonces.Next(context.Background(), messenger, nonceSender, 0)
+			if err != nil {
+				t.Error(err)
+			}
+			handedOut[i] = nonce
+		}(i)
+	}
+	wait.Wait()
+	sort.Slice(handedOut, func(i, j int) bool { return handedOut[i] < handedOut[j] })
+	for i, nonce := range handedOut {
+		if nonce != uint64(5+i) {
+			t.Fatalf("nonces handed out are not 5 to %d in turn: %v", 5+senders-1, handedOut)
+		}
+	}
+	if asks != 1 {
+		t.Errorf("node asked %d times for the nonce, expected once", asks)
+	}
+	// Other shards are counted apart
+	if nonce, _ := nonces.Next(context.Background(), messenger, nonceSender, 1); nonce != 5 {
+		t.Errorf("shard 1 started at %d", nonce)
+	}
+}
+
+func TestNonceManagerReleasesAndResyncs(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	count, asks := uint64(5), int32(0)
+	countedNonces(node, &count, &asks)
+	messenger, nonces := rpc.NewHTTPHandler(node.URL), NewNonceManager()
+	next := func() uint64 {
+		nonce, err := nonces.Next(context.Background(), messenger, nonceSender, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nonce
+	}
+
+	first, second, third := next(), next(), next()
+	// A transaction that never reached the node gives its nonce back
+	nonces.Release(nonceSender, 0, second)
+	if nonce := next(); nonce != second {
+		t.Errorf("expected released nonce %d, got %d", second, nonce)
+	}
+	nonces.Done(nonceSender, 0, third, nil)
+
+	// One sent without an answer may have landed, the node is asked again
+	nonces.Done(nonceSender, 0, second, errors.New("connection reset by peer"))
+	if nonce := next(); nonce != third+1 || asks != 2 {
+		t.Errorf("expected a resync to %d, got %d after %d queries", third+1, nonce, asks)
+	}
+
+	// The node missing an earlier nonce makes the manager follow it again
+	atomic.StoreUint64(&count, 9)
+	nonces.Done(nonceSender, 0, first, &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: "invalid nonce 5, expected 9"})
+	if nonce := next(); nonce != 9 || asks != 3 {
+		t.Errorf("expected a resync to 9, got %d after %d queries", nonce, asks)
+	}
+
+	// A node lagging behind what it just refused as too low is not followed below it
+	nonces.Done(nonceSender, 0, 9, &rpc.RPCError{Code: rpc.ErrVerifyRejected.Code, Message: "nonce too low"})
+	if nonce := next(); nonce != 10 || asks != 4 {
+		t.Errorf("expected a resync to 10, got %d after %d queries", nonce, asks)
+	}
+
+	nonces.Reset(nonceSender, 0)
+	if nonce := next(); nonce != 9 || asks != 5 {
+		t.Errorf("expected 9 again after a reset, got %d after %d queries", nonce, asks)
+	}
+}
+
+func TestNonceManagerResyncKeepsOutstandingNonces(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	count, asks := uint64(5), int32(0)
+	countedNonces(node, &count, &asks)
+	messenger, nonces := rpc.NewHTTPHandler(node.URL), NewNonceManager()
+	next := func() uint64 {
+		nonce, err := nonces.Next(context.Background(), messenger, nonceSender, 0)
+		if err != nil {
+			t.Error(err)
+		}
+		return nonce
+	}
+
+	const senders = 10
+	for i := 0; i < senders; i++ {
+		next()
+	}
+	// 5 to 9 land though their senders time out, 10 to 14 are still being signed
+	atomic.StoreUint64(&count, 10)
+	var wait sync.WaitGroup
+	var lock sync.Mutex
+	handedOut := make(map[uint64]bool)
+	for i := 0; i < senders/2; i++ {
+		wait.Add(2)
+		go func(nonce uint64) {
+			defer wait.Done()
+			nonces.Done(nonceSender, 0, nonce, context.DeadlineExceeded)
+		}(uint64(5 + i))
+		go func() {
+			defer wait.Done()
+			nonce := next()
+			lock.Lock()
+			defer lock.Unlock()
+			if nonce < 15 || handedOut[nonce] {
+				t.Errorf("nonce %d handed out again", nonce)
+			}
+			handedOut[nonce] = true
+		}()
+	}
+	wait.Wait()
+	if asks < 2 {
+		t.Errorf("node asked %d times for the nonce, expected a resync", asks)
+	}
+
+	// One held through the resync and never sent is handed out again
+	nonces.Release(nonceSender, 0, 12)
+	if nonce := next(); nonce != 12 {
+		t.Errorf("expected released nonce 12, got %d", nonce)
+	}
+}
+
+func TestNonceManagerResyncsAfterSendTimeout(t *testing.T) {
+	node := mocknode.New(common.Chain.TestNet)
+	defer node.Close()
+	ks, account, cleanup := unlockedAccount(t)
+	defer cleanup()
+	from := address.ToBech32(account.Address)
+	node.SetBalance(from, big.NewInt(denominations.One))
+	// The node takes the transaction but answers after the sender gave up
+	apply := node.Handler(rpc.Method.SendRawTransaction)
+	node.Handle(rpc.Method.SendRawTransaction, func(params []json.RawMessage) (interface{}, error) {
+		hash, err := apply(params)
+		time.Sleep(500 * time.Millisecond)
+		return hash, err
+	})
+	messenger, nonces := rpc.NewHTTPHandler(node.URL), NewNonceManager()
+	send := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return NewController(messenger, ks, &account, common.Chain.TestNet, WithContext(ctx), WithNonceManager(nonces)).
+			ExecuteTransaction(nonceSender, nil, big.NewInt(1), 1, 0, 0)
+	}
+
+	if err := send(250 * time.Millisecond); err == nil {
+		t.Fatal("expected the send to time out")
+	}
+	if landed := node.Nonce(from); landed != 1 {
+		t.Fatalf("the transaction did not land, the node counts %d", landed)
+	}
+	// Reusing nonce 0 would have the node refuse the second transaction
+	if err := send(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if landed := node.Nonce(from); landed != 2 {
+		t.Errorf("expected 2 transactions to land, the node counts %d", landed)
+	}
+}
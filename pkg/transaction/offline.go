@@ -0,0 +1,134 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/go-sdk/pkg/address"
+	"github.com/pkg/errors"
+)
+
+// OfflineFormatVersion is written to every transaction file, files of a later version are refused
+const OfflineFormatVersion = 1
+
+// UnsignedTransaction is the file `hmy tx build` writes and `hmy tx sign` reads,
+// everything the signer needs without reaching a node. Amounts are decimal strings in atto
+type UnsignedTransaction struct {
+	Version   int           `json:"version"`
+	ChainID   string        `json:"chain-id"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	FromShard uint32        `json:"from-shard"`
+	ToShard   uint32        `json:"to-shard"`
+	Nonce     uint64        `json:"nonce"`
+	GasLimit  uint64        `json:"gas-limit"`
+	GasPrice  string        `json:"gas-price"`
+	Amount    string        `json:"amount"`
+	Data      hexutil.Bytes `json:"data"`
+}
+
+// SignedTransaction is the file `hmy tx sign` writes and `hmy tx send` reads,
+// Raw is the hex encoded RLP taken by hmy_sendRawTransaction
+type SignedTransaction struct {
+	Version   int    `json:"version"`
+	ChainID   string `json:"chain-id"`
+	From      string `json:"from"`
+	FromShard uint32 `json:"from-shard"`
+	Hash      string `json:"hash"`
+	Raw       string `json:"raw"`
+}
+
+// Transaction is the unsigned transaction described by the file
+func (U *UnsignedTransaction) Transaction() (*Transaction, error) {
+	if err := checkVersion(U.Version); err != nil {
+		return nil, err
+	}
+	if _, err := address.Bech32ToAddress(U.To); err != nil {
+		return nil, errors.Wrapf(err, "invalid receiver %s", U.To)
+	}
+	gasPrice, ok := big.NewInt(0).SetString(U.GasPrice, 10)
+	if !ok || gasPrice.Sign() < 0 {
+		return nil, fmt.Errorf("invalid gas-price %s, expected a whole number of atto", U.GasPrice)
+	}
+	amount, ok := big.NewInt(0).SetString(U.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %s, expected a whole number of atto", U.Amount)
+	}
+	return NewTransaction(
+		U.Nonce, U.GasLimit, address.Parse(U.To), U.FromShard, U.ToShard, amount, gasPrice, U.Data,
+	), nil
+}
+
+// LoadUnsignedTransaction reads the file written by `hmy tx build`
+func LoadUnsignedTransaction(path string) (*UnsignedTransaction, error) {
+	unsigned := &UnsignedTransaction{}
+	if err := loadFile(path, unsigned); err != nil {
+		return nil, err
+	}
+	if _, err := unsigned.Transaction(); err != nil {
+		return nil, errors.Wrapf(err, "invalid unsigned transaction file %s", path)
+	}
+	return unsigned, nil
+}
+
+// LoadSignedTransaction reads the file written by `hmy tx sign`
+func LoadSignedTransaction(path string) (*SignedTransaction, error) {
+	signed := &SignedTransaction{}
+	if err := loadFile(path, signed); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(signed.Version); err != nil {
+		return nil, errors.Wrapf(err, "invalid signed transaction file %s", path)
+	}
+	if _, err := DecodeRawTransaction(signed.Raw); err != nil {
+		return nil, errors.Wrapf(err, "invalid signed transaction file %s", path)
+	}
+	return signed, nil
+}
+
+// DecodeRawTransaction reads a signed transaction as hex encoded RLP
+func DecodeRawTransaction(raw string) (*Transaction, error) {
+	encoded, err := hexutil.Decode(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "raw transaction is not 0x prefixed hex")
+	}
+	tx := &Transaction{}
+	if err := rlp.DecodeBytes(encoded, tx); err != nil {
+		return nil, errors.Wrap(err, "raw transaction is not an RLP encoded transaction")
+	}
+	return tx, nil
+}
+
+// Save writes the file for `hmy tx sign`, readable only by its owner
+func (U *UnsignedTransaction) Save(path string) error {
+	return saveFile(path, U)
+}
+
+// Save writes the file for `hmy tx send`, readable only by its owner
+func (S *SignedTransaction) Save(path string) error {
+	return saveFile(path, S)
+}
+
+func checkVersion(version int) error {
+	if version < 1 || version > OfflineFormatVersion {
+		return fmt.Errorf("unsupported transaction file version %d, expected %d", version, OfflineFormatVersion)
+	}
+	return nil
+}
+
+func loadFile(path string, into interface{}) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(json.Unmarshal(raw, into), "could not decode %s", path)
+}
+
+func saveFile(path string, from interface{}) error {
+	raw, _ := json.MarshalIndent(from, "", "  ")
+	return ioutil.WriteFile(path, append(raw, '\n'), 0600)
+}